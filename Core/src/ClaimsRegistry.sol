@@ -34,7 +34,7 @@ contract ClaimsRegistry is AccessControl, ReentrancyGuard, Pausable {
     struct Claim {
         bytes32 claimId;
         address provider;
-        bytes32 dataHash; // Keccak256 of claim data
+        bytes32 dataHash; // Merkle root of the claim data's fields
         string ipfsCid; // IPFS Content ID for full data
         uint256 amount; // Claim amount in wei (or smallest unit)
         uint256 submittedAt;
@@ -104,6 +104,7 @@ contract ClaimsRegistry is AccessControl, ReentrancyGuard, Pausable {
     error EmptyIPFSCid();
     error VerificationWindowExpired(bytes32 claimId);
     error InsufficientVerifications(uint256 current, uint256 required);
+    error ClaimDataNotVerifiable();
 
     // ============ Constructor ============
     constructor(address _providerRegistry) {
@@ -116,7 +117,7 @@ contract ClaimsRegistry is AccessControl, ReentrancyGuard, Pausable {
 
     /**
      * @notice Submit a new healthcare claim
-     * @param dataHash Keccak256 hash of the claim data
+     * @param dataHash Merkle root of the claim data's fields
      * @param ipfsCid IPFS Content ID where full claim data is stored
      * @param amount Claim amount
      * @return claimId The unique identifier for this claim
@@ -413,16 +414,17 @@ contract ClaimsRegistry is AccessControl, ReentrancyGuard, Pausable {
 
     /**
      * @notice Verify claim data integrity
-     * @param claimId The claim to verify
-     * @param data The original claim data
-     * @return valid Whether the hash matches
+     * @dev Deprecated: dataHash is a Merkle root over the claim's salted fields,
+     * not a hash of the raw data, so it cannot be checked here. Always reverts;
+     * verify fields against dataHash with inclusion proofs from the backend
+     * (POST /claims/:id/proof and POST /claims/:id/proof/verify).
      */
-    function verifyClaimData(bytes32 claimId, bytes calldata data)
+    function verifyClaimData(bytes32, bytes calldata)
         external
-        view
-        returns (bool valid)
+        pure
+        returns (bool)
     {
-        return claims[claimId].dataHash == keccak256(data);
+        revert ClaimDataNotVerifiable();
     }
 
     // ============ Admin Functions ============
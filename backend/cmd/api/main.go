@@ -14,9 +14,18 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/saintparish4/apx/internal/api"
+	"github.com/saintparish4/apx/internal/attachment"
+	"github.com/saintparish4/apx/internal/codeset"
 	"github.com/saintparish4/apx/internal/config"
+	"github.com/saintparish4/apx/internal/coverage"
+	"github.com/saintparish4/apx/internal/domain"
 	"github.com/saintparish4/apx/internal/ethereum"
+	"github.com/saintparish4/apx/internal/feeschedule"
 	"github.com/saintparish4/apx/internal/ipfs"
+	"github.com/saintparish4/apx/internal/ncci"
+	"github.com/saintparish4/apx/internal/payer"
+	"github.com/saintparish4/apx/internal/plugin"
+	"github.com/saintparish4/apx/internal/store"
 	"github.com/saintparish4/apx/internal/verifier"
 )
 
@@ -53,18 +62,131 @@ func main() {
 
 	// Initialize IPFS Service
 	// Generate or load encryption key ( TODO: in production, use proper key management)
-	encryptionKey, err := getOrCreateEncryptionKey()
+	keyring, err := ipfs.KeyringFromEnv()
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to get encryption key")
+		log.Fatal().Err(err).Msg("Failed to get encryption keys")
 	}
+	log.Info().Str("key_id", keyring.ActiveKeyID()).Msg("Loaded encryption keys")
 
-	ipfsService := ipfs.NewService(cfg.IPFSAPIURL, cfg.IPFSGatewayURL, encryptionKey)
+	ipfsService := ipfs.NewService(cfg.IPFSAPIURL, cfg.IPFSGatewayURL, keyring)
+
+	// Load reference code sets
+	codeSets, err := codeset.Load(cfg.CodeSetDir)
+	if err != nil {
+		log.Fatal().Err(err).Str("dir", cfg.CodeSetDir).Msg("Failed to load code sets")
+	}
+	for system, versions := range codeSets.Versions() {
+		log.Info().Str("system", string(system)).Strs("versions", versions).Msg("Loaded code set")
+	}
+
+	// Load fee schedules
+	feeSchedule, err := feeschedule.Load(cfg.FeeScheduleDir)
+	if err != nil {
+		log.Fatal().Err(err).Str("dir", cfg.FeeScheduleDir).Msg("Failed to load fee schedules")
+	}
+	log.Info().Strs("versions", feeSchedule.Versions()).Msg("Loaded fee schedules")
+
+	// Load NCCI edit tables
+	ncciEdits, err := ncci.Load(cfg.NCCIDir)
+	if err != nil {
+		log.Fatal().Err(err).Str("dir", cfg.NCCIDir).Msg("Failed to load NCCI edits")
+	}
+	ptpVersions, mueVersions := ncciEdits.Versions()
+	log.Info().Strs("ptp", ptpVersions).Strs("mue", mueVersions).Msg("Loaded NCCI edits")
+
+	// Load coverage policies
+	coveragePolicies, err := coverage.Load(cfg.CoverageDir)
+	if err != nil {
+		log.Fatal().Err(err).Str("dir", cfg.CoverageDir).Msg("Failed to load coverage policies")
+	}
+	log.Info().Strs("policies", coveragePolicies.IDs()).Msg("Loaded coverage policies")
+
+	// Load supporting-document requirements
+	documentRequirements, err := attachment.Load(cfg.AttachmentDir)
+	if err != nil {
+		log.Fatal().Err(err).Str("dir", cfg.AttachmentDir).Msg("Failed to load document requirements")
+	}
+	log.Info().Strs("requirements", documentRequirements.IDs()).Msg("Loaded document requirements")
+
+	// Load payer profiles
+	payers, err := payer.Load(cfg.PayerDir)
+	if err != nil {
+		log.Fatal().Err(err).Str("dir", cfg.PayerDir).Msg("Failed to load payer profiles")
+	}
+	for _, p := range payers.Profiles() {
+		log.Info().Str("payer", p.ID).Int("timely_filing_days", p.TimelyFilingDays).Msg("Loaded payer profile")
+	}
+
+	// Load validation rulesets; older versions stay available for replays
+	rulesets, err := verifier.LoadRulesets(cfg.RulesetDir)
+	if err != nil {
+		log.Fatal().Err(err).Str("dir", cfg.RulesetDir).Msg("Failed to load rulesets")
+	}
+	ruleset := verifier.DefaultRuleset()
+	if cfg.RulesetVersion != "" {
+		var ok bool
+		if ruleset, ok = rulesets[cfg.RulesetVersion]; !ok {
+			log.Fatal().Str("version", cfg.RulesetVersion).Str("dir", cfg.RulesetDir).Msg("Active ruleset not found")
+		}
+	}
+	log.Info().Str("active", ruleset.Version).Int("available", len(rulesets)).Msg("Loaded validation rulesets")
+
+	// Load WebAssembly plugin rules
+	plugins, err := plugin.Load(context.Background(), cfg.PluginDir, plugin.Limits{
+		Timeout:       cfg.PluginTimeout,
+		MemoryBytes:   uint32(cfg.PluginMemoryLimitMB) << 20,
+		MaxConcurrent: cfg.PluginMaxConcurrent,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Str("dir", cfg.PluginDir).Msg("Failed to load plugins")
+	}
+	defer plugins.Close(context.Background())
+	for _, p := range plugins.Rules() {
+		log.Info().Str("plugin", p.Name).Str("severity", p.Severity).Str("hash", p.Hash).Msg("Loaded plugin rule")
+	}
+
+	verifierOpts := []verifier.Option{
+		verifier.WithRuleset(ruleset),
+		verifier.WithRulesets(rulesets),
+		verifier.WithCodeSets(codeSets),
+		verifier.WithFeeSchedule(feeSchedule),
+		verifier.WithNCCIEdits(ncciEdits),
+		verifier.WithCoveragePolicies(coveragePolicies),
+		verifier.WithDocumentRequirements(documentRequirements),
+		verifier.WithPayerProfiles(payers),
+		verifier.WithPlugins(plugins),
+	}
+
+	// Connect to the off-chain database (optional: checks that need it are skipped)
+	db, err := store.Open(context.Background(), cfg.DatabaseURL)
+	if err != nil {
+		log.Warn().Err(err).Msg("Database unavailable, NPPES, duplicate and provider anomaly checks, verdict history and review queue disabled")
+	} else {
+		defer db.Close()
+		verifierOpts = append(verifierOpts,
+			verifier.WithProviderDirectory(db),
+			verifier.WithClaimIndex(db),
+			verifier.WithAnomalyScoring(db),
+			verifier.WithVerdictStore(db),
+			verifier.WithReviewQueue(db),
+		)
+	}
 
 	// Initialize Verifier Node
-	verifierNode := verifier.NewNode(ethService, ipfsService)
+	verifierNode := verifier.NewNode(ethService, ipfsService, verifierOpts...)
 
 	// Initialize API Handler
-	handler := api.NewHandler(ethService, ipfsService, verifierNode)
+	// Patient identifiers are hashed with a key shared by every API instance
+	identifierKey, err := getOrCreateIdentifierKey()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to get identifier key")
+	}
+	identifiers, err := domain.NewIdentifierHasher(identifierKey)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid identifier key")
+	}
+
+	handler := api.NewHandler(ethService, ipfsService, verifierNode, codeSets, identifiers)
 
 	// Set up Gin router
 	if cfg.Environment == "production" {
@@ -96,6 +218,19 @@ func main() {
 		claims.GET("/:id", handler.GetClaim)
 		claims.GET("/data/:cid", handler.GetClaimData)
 		claims.POST("/validate", handler.ValidateClaim)
+		claims.POST("/hash", handler.HashClaim)
+		claims.POST("/x12", handler.SubmitX12)
+		claims.POST("/:id/replay", handler.ReplayClaim)
+		claims.POST("/:id/proof", handler.ProveClaim)
+		claims.POST("/:id/proof/verify", handler.VerifyClaimProof)
+	}
+
+	// FHIR routes
+	fhirRoutes := router.Group("/fhir")
+	{
+		fhirRoutes.POST("/Claim", handler.SubmitFHIRClaim)
+		fhirRoutes.POST("/Claim/$validate", handler.ValidateFHIRClaim)
+		fhirRoutes.GET("/ClaimResponse/:id", handler.GetFHIRClaimResponse)
 	}
 
 	// Providers routes
@@ -103,11 +238,30 @@ func main() {
 	{
 		providers.GET("", handler.ListProviders)
 		providers.GET("/:address", handler.GetProvider)
+		providers.GET("/:address/remittance", handler.GetRemittance)
+	}
+
+	// Human review queue routes
+	reviews := router.Group("/reviews")
+	{
+		reviews.GET("", handler.ListReviews)
+		reviews.GET("/:id", handler.GetReview)
+		reviews.POST("/:id/claim", handler.ClaimReview)
+		reviews.POST("/:id/notes", handler.AnnotateReview)
+		reviews.POST("/:id/decision", handler.DecideReview)
 	}
 
 	// Documents routes
 	router.POST("/documents", handler.UploadDocument)
 
+	// Medical code lookup routes
+	codes := router.Group("/codes")
+	{
+		codes.GET("", handler.GetMedicalCoding)
+		codes.GET("/:system", handler.SearchCodes)
+		codes.GET("/:system/:code", handler.GetCode)
+	}
+
 	// WebSocket for real-time updates (placeholder)
 	router.GET("/ws", func(c *gin.Context) {
 		c.JSON(http.StatusNotImplemented, gin.H{
@@ -193,14 +347,15 @@ func requestLogger() gin.HandlerFunc {
 	}
 }
 
-// getOrCreateEncryptionKey gets or creates a 32-byte AES encryption key
-func getOrCreateEncryptionKey() ([]byte, error) {
-	keyHex := os.Getenv("IPFS_ENCRYPTION_KEY")
+// getOrCreateIdentifierKey gets or creates the HMAC key for patient
+// identifiers and dates of birth
+func getOrCreateIdentifierKey() ([]byte, error) {
+	keyHex := os.Getenv("IDENTIFIER_HMAC_KEY")
 	if keyHex != "" {
 		return hex.DecodeString(keyHex)
 	}
 
 	// For development, use a fixed key (DO NOT USE IN PRODUCTION)
-	log.Warn().Msg("Using default encryption key - NOT SECURE FOR PRODUCTION")
-	return []byte("12345678901234567890123456789012"), nil // 32 bytes for AES-256
+	log.Warn().Msg("Using default identifier key - NOT SECURE FOR PRODUCTION")
+	return []byte("apx-development-identifier-key-0"), nil // 32 bytes
 }
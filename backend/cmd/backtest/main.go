@@ -0,0 +1,305 @@
+// Command backtest replays a corpus of claims under the active ruleset and a
+// candidate ruleset and reports what the candidate would change: flipped
+// approvals, score distribution shifts and per-rule hit counts.
+//
+//	go run ./cmd/backtest -claims testdata/claims.ndjson -candidate candidate.json
+//	go run ./cmd/backtest -cids cids.txt -current 2026.10 -candidate candidate.json -json
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/saintparish4/apx/internal/attachment"
+	"github.com/saintparish4/apx/internal/codeset"
+	"github.com/saintparish4/apx/internal/config"
+	"github.com/saintparish4/apx/internal/coverage"
+	"github.com/saintparish4/apx/internal/domain"
+	"github.com/saintparish4/apx/internal/feeschedule"
+	"github.com/saintparish4/apx/internal/ipfs"
+	"github.com/saintparish4/apx/internal/ncci"
+	"github.com/saintparish4/apx/internal/payer"
+	"github.com/saintparish4/apx/internal/plugin"
+	"github.com/saintparish4/apx/internal/refdata"
+	"github.com/saintparish4/apx/internal/store"
+	"github.com/saintparish4/apx/internal/verifier"
+)
+
+func main() {
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load configuration")
+	}
+
+	claimsPath := flag.String("claims", "", "directory of claim JSON/NDJSON files, or a single NDJSON file")
+	cidsPath := flag.String("cids", "", "file of IPFS CIDs to fetch, one per line")
+	current := flag.String("current", cfg.RulesetVersion, "current ruleset: a version in the ruleset directory or a JSON file")
+	candidate := flag.String("candidate", "", "candidate ruleset: a version in the ruleset directory or a JSON file")
+	asOf := flag.String("as-of", "", "evaluation time for date checks, YYYY-MM-DD or RFC 3339 (default: now)")
+	databaseURL := flag.String("database-url", "", "PostgreSQL connection URL; enables NPPES and duplicate checks")
+	asJSON := flag.Bool("json", false, "write the report as JSON")
+	maxFlips := flag.Int("flips", 50, "flipped claims to list in the text report (0 for all)")
+	flag.Parse()
+
+	if (*claimsPath == "") == (*cidsPath == "") || *candidate == "" {
+		fmt.Fprintln(os.Stderr, "exactly one of -claims or -cids, and -candidate, are required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	evaluatedAt := time.Now()
+	if *asOf != "" {
+		if evaluatedAt, err = parseAsOf(*asOf); err != nil {
+			log.Fatal().Err(err).Msg("Invalid -as-of")
+		}
+	}
+
+	currentRuleset, err := loadRuleset(cfg.RulesetDir, *current)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load current ruleset")
+	}
+	candidateRuleset, err := loadRuleset(cfg.RulesetDir, *candidate)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load candidate ruleset")
+	}
+
+	ctx := context.Background()
+
+	opts, err := referenceDataOptions(ctx, cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load reference data")
+	}
+	if *databaseURL != "" {
+		db, err := store.Open(ctx, *databaseURL)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to connect to database")
+		}
+		defer db.Close()
+		opts = append(opts, verifier.WithProviderDirectory(db), verifier.WithClaimIndex(db))
+	}
+
+	// The same payload keys as the API server
+	keyring, err := ipfs.KeyringFromEnv()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid IPFS encryption keys")
+	}
+	ipfsService := ipfs.NewService(cfg.IPFSAPIURL, cfg.IPFSGatewayURL, keyring)
+
+	// The nodes differ only in ruleset; both see the same reference data
+	currentNode := verifier.NewNode(nil, ipfsService, append(opts[:len(opts):len(opts)], verifier.WithRuleset(currentRuleset))...)
+	candidateNode := verifier.NewNode(nil, ipfsService, append(opts[:len(opts):len(opts)], verifier.WithRuleset(candidateRuleset))...)
+
+	bt := newBacktest()
+
+	run := func(id string, data *domain.ClaimData) {
+		before, err := currentNode.Replay(ctx, &verifier.Submission{ClaimData: data}, currentRuleset.Version, evaluatedAt)
+		if err != nil {
+			bt.fail(id, err)
+			return
+		}
+		after, err := candidateNode.Replay(ctx, &verifier.Submission{ClaimData: data}, candidateRuleset.Version, evaluatedAt)
+		if err != nil {
+			bt.fail(id, err)
+			return
+		}
+		bt.add(id, before, after)
+	}
+
+	if *claimsPath != "" {
+		err = readClaims(*claimsPath, run, bt.fail)
+	} else {
+		err = readCIDs(ctx, ipfsService, *cidsPath, run, bt.fail)
+	}
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to read claims")
+	}
+
+	rep := bt.report()
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(rep)
+	} else {
+		err = rep.writeText(os.Stdout, *maxFlips)
+	}
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to write report")
+	}
+}
+
+// loadRuleset resolves a ruleset given as a JSON file path or as a version
+// in the ruleset directory
+func loadRuleset(dir, ref string) (*verifier.Ruleset, error) {
+	if strings.HasSuffix(ref, ".json") {
+		return verifier.LoadRuleset(ref)
+	}
+	if ref == "" {
+		return verifier.DefaultRuleset(), nil
+	}
+	return verifier.LoadRuleset(filepath.Join(dir, ref+".json"))
+}
+
+// referenceDataOptions loads the same reference data the API server uses
+func referenceDataOptions(ctx context.Context, cfg *config.Config) ([]verifier.Option, error) {
+	codeSets, err := codeset.Load(cfg.CodeSetDir)
+	if err != nil {
+		return nil, fmt.Errorf("code sets: %w", err)
+	}
+	feeSchedule, err := feeschedule.Load(cfg.FeeScheduleDir)
+	if err != nil {
+		return nil, fmt.Errorf("fee schedules: %w", err)
+	}
+	ncciEdits, err := ncci.Load(cfg.NCCIDir)
+	if err != nil {
+		return nil, fmt.Errorf("NCCI edits: %w", err)
+	}
+	policies, err := coverage.Load(cfg.CoverageDir)
+	if err != nil {
+		return nil, fmt.Errorf("coverage policies: %w", err)
+	}
+	documentRequirements, err := attachment.Load(cfg.AttachmentDir)
+	if err != nil {
+		return nil, fmt.Errorf("document requirements: %w", err)
+	}
+	payers, err := payer.Load(cfg.PayerDir)
+	if err != nil {
+		return nil, fmt.Errorf("payer profiles: %w", err)
+	}
+	plugins, err := plugin.Load(ctx, cfg.PluginDir, plugin.Limits{
+		Timeout:       cfg.PluginTimeout,
+		MemoryBytes:   uint32(cfg.PluginMemoryLimitMB) << 20,
+		MaxConcurrent: cfg.PluginMaxConcurrent,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("plugins: %w", err)
+	}
+
+	return []verifier.Option{
+		verifier.WithCodeSets(codeSets),
+		verifier.WithFeeSchedule(feeSchedule),
+		verifier.WithNCCIEdits(ncciEdits),
+		verifier.WithCoveragePolicies(policies),
+		verifier.WithDocumentRequirements(documentRequirements),
+		verifier.WithPayerProfiles(payers),
+		verifier.WithPlugins(plugins),
+	}, nil
+}
+
+// readClaims reads a single NDJSON file or every .json, .ndjson and .jsonl
+// file in a directory. A .json file holds one claim; the others hold one per
+// line.
+func readClaims(path string, run func(string, *domain.ClaimData), fail func(string, error)) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files = nil
+		for _, ext := range []string{".json", ".ndjson", ".jsonl"} {
+			matched, err := refdata.ListFiles(path, ext)
+			if err != nil {
+				return err
+			}
+			files = append(files, matched...)
+		}
+	}
+
+	for _, file := range files {
+		if strings.HasSuffix(file, ".json") {
+			raw, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			var data domain.ClaimData
+			if err := json.Unmarshal(raw, &data); err != nil {
+				fail(file, err)
+				continue
+			}
+			run(file, &data)
+			continue
+		}
+
+		if err := readNDJSON(file, run, fail); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readNDJSON(file string, run func(string, *domain.ClaimData), fail func(string, error)) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		id := fmt.Sprintf("%s:%d", file, line)
+		var data domain.ClaimData
+		if err := json.Unmarshal([]byte(text), &data); err != nil {
+			fail(id, err)
+			continue
+		}
+		run(id, &data)
+	}
+
+	return scanner.Err()
+}
+
+// readCIDs fetches each listed CID from IPFS. Blank lines and lines starting
+// with # are ignored.
+func readCIDs(ctx context.Context, ipfsService *ipfs.Service, file string, run func(string, *domain.ClaimData), fail func(string, error)) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		cid := strings.TrimSpace(scanner.Text())
+		if cid == "" || strings.HasPrefix(cid, "#") {
+			continue
+		}
+
+		fetchCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		data, err := ipfsService.RetrieveClaimData(fetchCtx, cid)
+		cancel()
+		if err != nil {
+			fail(cid, err)
+			continue
+		}
+		run(cid, data)
+	}
+
+	return scanner.Err()
+}
+
+func parseAsOf(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/saintparish4/apx/internal/domain"
+)
+
+// backtest accumulates paired results as claims are replayed
+type backtest struct {
+	current, candidate *domain.RulesetInfo
+
+	claims   int
+	failures []failure
+	flips    []flip
+
+	scores     [2][]float64
+	approved   [2]int
+	riskLevels map[string]*[2]int
+	ruleHits   map[string]*[2]int
+}
+
+type failure struct {
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
+// flip is a claim whose approval changed under the candidate ruleset
+type flip struct {
+	ID             string   `json:"id"`
+	Approved       bool     `json:"approved"` // under the candidate
+	CurrentScore   float64  `json:"current_score"`
+	CandidateScore float64  `json:"candidate_score"`
+	RulesAdded     []string `json:"rules_added,omitempty"`   // failing only under the candidate
+	RulesRemoved   []string `json:"rules_removed,omitempty"` // failing only under the current ruleset
+}
+
+func newBacktest() *backtest {
+	return &backtest{
+		riskLevels: map[string]*[2]int{},
+		ruleHits:   map[string]*[2]int{},
+	}
+}
+
+func (bt *backtest) fail(id string, err error) {
+	bt.failures = append(bt.failures, failure{ID: id, Error: err.Error()})
+}
+
+func (bt *backtest) add(id string, before, after *domain.ValidationResult) {
+	bt.claims++
+	if bt.current == nil {
+		bt.current, bt.candidate = before.Ruleset, after.Ruleset
+	}
+
+	hits := [2]map[string]bool{}
+	for i, result := range []*domain.ValidationResult{before, after} {
+		bt.scores[i] = append(bt.scores[i], result.Score)
+		if result.Approved {
+			bt.approved[i]++
+		}
+		counter(bt.riskLevels, result.RiskLevel)[i]++
+
+		// Count each rule once per claim, however many findings it produced
+		hits[i] = map[string]bool{}
+		for _, finding := range result.Findings {
+			if !hits[i][finding.Rule] {
+				hits[i][finding.Rule] = true
+				counter(bt.ruleHits, finding.Rule)[i]++
+			}
+		}
+	}
+
+	if before.Approved != after.Approved {
+		bt.flips = append(bt.flips, flip{
+			ID:             id,
+			Approved:       after.Approved,
+			CurrentScore:   before.Score,
+			CandidateScore: after.Score,
+			RulesAdded:     difference(hits[1], hits[0]),
+			RulesRemoved:   difference(hits[0], hits[1]),
+		})
+	}
+}
+
+func counter(counts map[string]*[2]int, key string) *[2]int {
+	if counts[key] == nil {
+		counts[key] = &[2]int{}
+	}
+	return counts[key]
+}
+
+// difference returns the keys of a that are not in b, sorted
+func difference(a, b map[string]bool) []string {
+	var keys []string
+	for key := range a {
+		if !b[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// report is the outcome of a backtest
+type report struct {
+	Current   *domain.RulesetInfo `json:"current"`
+	Candidate *domain.RulesetInfo `json:"candidate"`
+
+	Claims   int       `json:"claims"`
+	Failures []failure `json:"failures,omitempty"` // claims that could not be read or validated
+
+	Approved      comparison `json:"approved"`
+	NewlyApproved int        `json:"newly_approved"`
+	NewlyRejected int        `json:"newly_rejected"`
+	Flips         []flip     `json:"flips"`
+
+	Scores     scoreShift            `json:"scores"`
+	RiskLevels map[string]comparison `json:"risk_levels"`
+	RuleHits   []ruleHits            `json:"rule_hits"`
+}
+
+type comparison struct {
+	Current   int `json:"current"`
+	Candidate int `json:"candidate"`
+}
+
+type ruleHits struct {
+	Rule string `json:"rule"`
+	comparison
+	Delta int `json:"delta"`
+}
+
+type scoreShift struct {
+	Current   distribution `json:"current"`
+	Candidate distribution `json:"candidate"`
+	MeanShift float64      `json:"mean_shift"`
+	Raised    int          `json:"raised"`  // claims scoring higher under the candidate
+	Lowered   int          `json:"lowered"` // claims scoring lower under the candidate
+}
+
+// distribution summarizes scores; Histogram counts scores in ten-point
+// buckets, with 100 in the last
+type distribution struct {
+	Mean      float64 `json:"mean"`
+	Median    float64 `json:"median"`
+	P10       float64 `json:"p10"`
+	P90       float64 `json:"p90"`
+	Histogram [10]int `json:"histogram"`
+}
+
+func (bt *backtest) report() *report {
+	rep := &report{
+		Current:    bt.current,
+		Candidate:  bt.candidate,
+		Claims:     bt.claims,
+		Failures:   bt.failures,
+		Approved:   comparison{bt.approved[0], bt.approved[1]},
+		Flips:      bt.flips,
+		RiskLevels: map[string]comparison{},
+		RuleHits:   []ruleHits{},
+	}
+	if rep.Flips == nil {
+		rep.Flips = []flip{}
+	}
+
+	for _, f := range bt.flips {
+		if f.Approved {
+			rep.NewlyApproved++
+		} else {
+			rep.NewlyRejected++
+		}
+	}
+
+	for i := range bt.scores[0] {
+		switch {
+		case bt.scores[1][i] > bt.scores[0][i]:
+			rep.Scores.Raised++
+		case bt.scores[1][i] < bt.scores[0][i]:
+			rep.Scores.Lowered++
+		}
+	}
+	rep.Scores.Current = summarize(bt.scores[0])
+	rep.Scores.Candidate = summarize(bt.scores[1])
+	rep.Scores.MeanShift = rep.Scores.Candidate.Mean - rep.Scores.Current.Mean
+
+	for level, counts := range bt.riskLevels {
+		rep.RiskLevels[level] = comparison{counts[0], counts[1]}
+	}
+
+	for rule, counts := range bt.ruleHits {
+		rep.RuleHits = append(rep.RuleHits, ruleHits{
+			Rule:       rule,
+			comparison: comparison{counts[0], counts[1]},
+			Delta:      counts[1] - counts[0],
+		})
+	}
+	// Biggest changes first, then by name
+	sort.Slice(rep.RuleHits, func(i, j int) bool {
+		a, b := rep.RuleHits[i], rep.RuleHits[j]
+		if abs(a.Delta) != abs(b.Delta) {
+			return abs(a.Delta) > abs(b.Delta)
+		}
+		return a.Rule < b.Rule
+	})
+
+	return rep
+}
+
+func summarize(scores []float64) distribution {
+	var d distribution
+	if len(scores) == 0 {
+		return d
+	}
+
+	sorted := append([]float64(nil), scores...)
+	sort.Float64s(sorted)
+
+	sum := 0.0
+	for _, score := range sorted {
+		sum += score
+		bucket := int(score / 10)
+		if bucket > 9 {
+			bucket = 9
+		}
+		if bucket < 0 {
+			bucket = 0
+		}
+		d.Histogram[bucket]++
+	}
+
+	d.Mean = sum / float64(len(sorted))
+	d.Median = percentile(sorted, 0.5)
+	d.P10 = percentile(sorted, 0.1)
+	d.P90 = percentile(sorted, 0.9)
+
+	return d
+}
+
+// percentile interpolates linearly between the closest ranks of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	rank := p * float64(len(sorted)-1)
+	low := int(math.Floor(rank))
+	high := int(math.Ceil(rank))
+	return sorted[low] + (sorted[high]-sorted[low])*(rank-float64(low))
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// writeText renders the report for a terminal, listing at most maxFlips
+// flipped claims (all when maxFlips is 0)
+func (rep *report) writeText(w io.Writer, maxFlips int) error {
+	var b strings.Builder
+
+	version := func(info *domain.RulesetInfo) string {
+		if info == nil {
+			return "-"
+		}
+		return fmt.Sprintf("%s (%s)", info.Version, info.Hash[:18])
+	}
+	fmt.Fprintf(&b, "Current ruleset:   %s\n", version(rep.Current))
+	fmt.Fprintf(&b, "Candidate ruleset: %s\n", version(rep.Candidate))
+	fmt.Fprintf(&b, "Claims replayed:   %d", rep.Claims)
+	if len(rep.Failures) > 0 {
+		fmt.Fprintf(&b, " (%d could not be read)", len(rep.Failures))
+	}
+	b.WriteString("\n\n")
+
+	b.WriteString("Approvals\n")
+	fmt.Fprintf(&b, "  approved          %6d -> %6d\n", rep.Approved.Current, rep.Approved.Candidate)
+	fmt.Fprintf(&b, "  newly approved    %6d\n", rep.NewlyApproved)
+	fmt.Fprintf(&b, "  newly rejected    %6d\n\n", rep.NewlyRejected)
+
+	b.WriteString("Scores                current  candidate\n")
+	for _, row := range []struct {
+		label string
+		a, b  float64
+	}{
+		{"mean", rep.Scores.Current.Mean, rep.Scores.Candidate.Mean},
+		{"median", rep.Scores.Current.Median, rep.Scores.Candidate.Median},
+		{"p10", rep.Scores.Current.P10, rep.Scores.Candidate.P10},
+		{"p90", rep.Scores.Current.P90, rep.Scores.Candidate.P90},
+	} {
+		fmt.Fprintf(&b, "  %-18s %8.1f  %9.1f\n", row.label, row.a, row.b)
+	}
+	fmt.Fprintf(&b, "  mean shift %+.1f, %d claims raised, %d lowered\n", rep.Scores.MeanShift, rep.Scores.Raised, rep.Scores.Lowered)
+	for i := 9; i >= 0; i-- {
+		label := fmt.Sprintf("%d-%d", i*10, i*10+9)
+		if i == 9 {
+			label = "90-100"
+		}
+		fmt.Fprintf(&b, "  %-18s %8d  %9d\n", label, rep.Scores.Current.Histogram[i], rep.Scores.Candidate.Histogram[i])
+	}
+	b.WriteString("\n")
+
+	b.WriteString("Risk levels           current  candidate\n")
+	for _, level := range []string{"low", "medium", "high"} {
+		counts := rep.RiskLevels[level]
+		fmt.Fprintf(&b, "  %-18s %8d  %9d\n", level, counts.Current, counts.Candidate)
+	}
+	b.WriteString("\n")
+
+	b.WriteString("Rule hits                           current  candidate    delta\n")
+	for _, hits := range rep.RuleHits {
+		fmt.Fprintf(&b, "  %-32s %8d  %9d  %+7d\n", hits.Rule, hits.Current, hits.Candidate, hits.Delta)
+	}
+
+	if len(rep.Flips) > 0 {
+		b.WriteString("\nFlipped claims\n")
+		for i, f := range rep.Flips {
+			if maxFlips > 0 && i == maxFlips {
+				fmt.Fprintf(&b, "  ... and %d more\n", len(rep.Flips)-maxFlips)
+				break
+			}
+			outcome := "rejected"
+			if f.Approved {
+				outcome = "approved"
+			}
+			fmt.Fprintf(&b, "  %s: now %s (score %.1f -> %.1f)", f.ID, outcome, f.CurrentScore, f.CandidateScore)
+			if len(f.RulesAdded) > 0 {
+				fmt.Fprintf(&b, " +%s", strings.Join(f.RulesAdded, " +"))
+			}
+			if len(f.RulesRemoved) > 0 {
+				fmt.Fprintf(&b, " -%s", strings.Join(f.RulesRemoved, " -"))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	if len(rep.Failures) > 0 {
+		b.WriteString("\nUnreadable claims\n")
+		for _, f := range rep.Failures {
+			fmt.Fprintf(&b, "  %s: %s\n", f.ID, f.Error)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,108 @@
+// Command nppes-import loads an NPPES bulk file (or a subset of one) into the
+// nppes_providers table used by the verifier's NPI checks.
+//
+//	go run ./cmd/nppes-import -file npidata_pfile.csv -states CA,NV
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/saintparish4/apx/internal/config"
+	"github.com/saintparish4/apx/internal/npi"
+	"github.com/saintparish4/apx/internal/store"
+)
+
+func main() {
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load configuration")
+	}
+
+	file := flag.String("file", "", "path to an NPPES npidata CSV file")
+	states := flag.String("states", "", "comma-separated practice states to import (default: all)")
+	batchSize := flag.Int("batch", 1000, "records per database transaction")
+	databaseURL := flag.String("database-url", cfg.DatabaseURL, "PostgreSQL connection URL")
+	flag.Parse()
+
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	stateFilter := map[string]bool{}
+	for _, state := range strings.Split(*states, ",") {
+		if state = strings.ToUpper(strings.TrimSpace(state)); state != "" {
+			stateFilter[state] = true
+		}
+	}
+
+	ctx := context.Background()
+
+	db, err := store.Open(ctx, *databaseURL)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to connect to database")
+	}
+	defer db.Close()
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to open NPPES file")
+	}
+	defer f.Close()
+
+	var (
+		batch    []*npi.Record
+		imported int
+		skipped  int
+	)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := db.UpsertNPPESRecords(ctx, batch); err != nil {
+			return err
+		}
+		imported += len(batch)
+		batch = batch[:0]
+		log.Info().Int("imported", imported).Msg("Imported batch")
+		return nil
+	}
+
+	err = npi.ReadNPPES(f, func(rec *npi.Record) error {
+		if !npi.Valid(rec.NPI) {
+			skipped++
+			return nil
+		}
+		// Deactivated entries have their address fields blanked by NPPES, so
+		// they are kept regardless of the state filter
+		if len(stateFilter) > 0 && rec.DeactivationDate.IsZero() && !stateFilter[strings.ToUpper(rec.State)] {
+			skipped++
+			return nil
+		}
+
+		batch = append(batch, rec)
+		if len(batch) >= *batchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		log.Fatal().Err(err).Int("imported", imported).Msg("NPPES import failed")
+	}
+
+	log.Info().
+		Int("imported", imported).
+		Int("skipped", skipped).
+		Msg("NPPES import completed")
+}
@@ -0,0 +1,55 @@
+//go:build wasip1
+
+// Command facility_required is an example plugin rule: inpatient and
+// skilled nursing claims must name the facility.
+//
+//	GOOS=wasip1 GOARCH=wasm go build -buildmode=c-shared -o facility_required.wasm ./examples/plugins/facility_required
+package main
+
+import (
+	"encoding/json"
+	"unsafe"
+)
+
+type claim struct {
+	FacilityID     string `json:"facility_id"`
+	PlaceOfService string `json:"place_of_service"`
+}
+
+type result struct {
+	Pass    bool   `json:"pass"`
+	Message string `json:"message,omitempty"`
+	Path    string `json:"path,omitempty"`
+}
+
+// Inpatient hospital, skilled nursing facility
+var facilityPlaces = map[string]bool{"21": true, "31": true}
+
+// buffers keeps memory handed to the host reachable until the instance exits
+var buffers [][]byte
+
+func main() {}
+
+//go:wasmexport apx_alloc
+func alloc(size uint32) uint32 {
+	buf := make([]byte, size)
+	buffers = append(buffers, buf)
+	return uint32(uintptr(unsafe.Pointer(unsafe.SliceData(buf))))
+}
+
+//go:wasmexport apx_check
+func check(ptr, size uint32) uint64 {
+	input := unsafe.Slice((*byte)(unsafe.Pointer(uintptr(ptr))), size)
+
+	var c claim
+	res := result{Pass: true}
+	if err := json.Unmarshal(input, &c); err != nil {
+		res = result{Message: "invalid claim JSON: " + err.Error()}
+	} else if facilityPlaces[c.PlaceOfService] && c.FacilityID == "" {
+		res = result{Message: "Place of service " + c.PlaceOfService + " requires a facility ID", Path: "facility_id"}
+	}
+
+	out, _ := json.Marshal(res)
+	buffers = append(buffers, out)
+	return uint64(uintptr(unsafe.Pointer(unsafe.SliceData(out))))<<32 | uint64(len(out))
+}
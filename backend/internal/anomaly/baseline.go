@@ -0,0 +1,272 @@
+// Package anomaly keeps rolling per-provider billing baselines and scores new
+// claims against them. Fraud patterns show up across many claims, so these
+// features complement the per-claim validation rules.
+package anomaly
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Config tunes how baselines roll and when a feature counts as anomalous
+type Config struct {
+	Window          int     `json:"window"`            // claims of memory for amount and code mix
+	VolumeWindow    int     `json:"volume_window"`     // days of memory for daily volume
+	MinClaims       int     `json:"min_claims"`        // claims needed before scoring amounts and codes
+	MinDays         int     `json:"min_days"`          // days needed before scoring volume
+	AmountZ         float64 `json:"amount_z"`          // z-score that flags a billed amount
+	VolumeRatio     float64 `json:"volume_ratio"`      // multiple of usual daily volume that flags a spike
+	MinSpikeVolume  int     `json:"min_spike_volume"`  // ignore spikes below this many claims a day
+	RareCodeShare   float64 `json:"rare_code_share"`   // codes below this share of history are unusual
+	HighLevelEMMax  float64 `json:"high_level_em_max"` // share of high-level E/M visits that flags upcoding
+	PenaltyPerAlert float64 `json:"penalty_per_alert"` // score deducted per anomalous feature
+}
+
+// DefaultConfig returns the thresholds used when none are configured
+func DefaultConfig() Config {
+	return Config{
+		Window:          200,
+		VolumeWindow:    30,
+		MinClaims:       20,
+		MinDays:         7,
+		AmountZ:         3.0,
+		VolumeRatio:     3.0,
+		MinSpikeVolume:  5,
+		RareCodeShare:   0.01,
+		HighLevelEMMax:  0.5,
+		PenaltyPerAlert: 10,
+	}
+}
+
+// highLevelEM are evaluation and management codes at the top complexity
+// levels, the usual target of upcoding
+var highLevelEM = map[string]bool{
+	"99204": true, "99205": true, // new patient office visit, levels 4-5
+	"99214": true, "99215": true, // established patient office visit, levels 4-5
+	"99223": true, "99233": true, // initial and subsequent hospital care, high
+	"99285": true, // emergency department, high severity
+}
+
+// evaluationAndManagement reports whether a code is an office, hospital or
+// ED E/M visit, the population the high-level share is measured over
+func evaluationAndManagement(code string) bool {
+	return strings.HasPrefix(code, "992")
+}
+
+// Observation is the part of a claim that baselines track
+type Observation struct {
+	Amount float64
+	Codes  []string // base procedure codes
+}
+
+// Baseline is a provider's rolling billing profile. Means and variances are
+// exponentially weighted so old behaviour fades out.
+type Baseline struct {
+	ProviderNPI string `json:"provider_npi"`
+	Claims      int64  `json:"claims"`
+
+	AmountMean float64 `json:"amount_mean"`
+	AmountVar  float64 `json:"amount_var"`
+
+	Day         string             `json:"day"` // UTC day the current count belongs to
+	DayCount    int                `json:"day_count"`
+	Days        int                `json:"days"` // completed days folded into the volume stats
+	VolumeMean  float64            `json:"volume_mean"`
+	VolumeVar   float64            `json:"volume_var"`
+	CodeWeights map[string]float64 `json:"code_weights"`
+
+	EMVisits     float64 `json:"em_visits"`
+	HighLevelEMs float64 `json:"high_level_ems"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewBaseline creates an empty baseline for a provider
+func NewBaseline(providerNPI string) *Baseline {
+	return &Baseline{
+		ProviderNPI: providerNPI,
+		CodeWeights: map[string]float64{},
+	}
+}
+
+// Observe folds a claim into the baseline
+func (b *Baseline) Observe(obs Observation, now time.Time, cfg Config) {
+	alpha := 2 / (float64(cfg.Window) + 1)
+
+	// Amount: exponentially weighted mean and variance
+	if b.Claims == 0 {
+		b.AmountMean = obs.Amount
+	} else {
+		diff := obs.Amount - b.AmountMean
+		incr := alpha * diff
+		b.AmountMean += incr
+		b.AmountVar = (1 - alpha) * (b.AmountVar + diff*incr)
+	}
+
+	// Code mix: decay every weight, then credit this claim's codes
+	if b.CodeWeights == nil {
+		b.CodeWeights = map[string]float64{}
+	}
+	for code, weight := range b.CodeWeights {
+		weight *= 1 - alpha
+		if weight < 1e-4 {
+			delete(b.CodeWeights, code)
+			continue
+		}
+		b.CodeWeights[code] = weight
+	}
+	for _, code := range obs.Codes {
+		b.CodeWeights[code] += alpha
+	}
+
+	// High-level E/M share
+	b.EMVisits *= 1 - alpha
+	b.HighLevelEMs *= 1 - alpha
+	if em, high := emLevels(obs.Codes); em {
+		b.EMVisits++
+		if high {
+			b.HighLevelEMs++
+		}
+	}
+
+	// Daily volume
+	b.rollDay(now, cfg)
+	b.DayCount++
+
+	b.Claims++
+	b.UpdatedAt = now
+}
+
+// rollDay folds completed days (including idle ones) into the volume stats
+func (b *Baseline) rollDay(now time.Time, cfg Config) {
+	today := now.UTC().Format("2006-01-02")
+	if b.Day == "" {
+		b.Day = today
+		return
+	}
+	if b.Day == today {
+		return
+	}
+
+	last, err := time.Parse("2006-01-02", b.Day)
+	if err != nil {
+		b.Day, b.DayCount = today, 0
+		return
+	}
+
+	alpha := 2 / (float64(cfg.VolumeWindow) + 1)
+	elapsed := int(now.UTC().Sub(last).Hours() / 24)
+	if elapsed > cfg.VolumeWindow {
+		elapsed = cfg.VolumeWindow // Older days have decayed away anyway
+	}
+
+	count := float64(b.DayCount)
+	for i := 0; i < elapsed; i++ {
+		if b.Days == 0 {
+			b.VolumeMean = count
+		} else {
+			diff := count - b.VolumeMean
+			incr := alpha * diff
+			b.VolumeMean += incr
+			b.VolumeVar = (1 - alpha) * (b.VolumeVar + diff*incr)
+		}
+		b.Days++
+		count = 0 // Days after the last active one had no claims
+	}
+
+	b.Day, b.DayCount = today, 0
+}
+
+// Factor is an anomalous feature of a claim relative to its provider
+type Factor struct {
+	Name   string  `json:"name"`
+	Value  float64 `json:"value"`
+	Detail string  `json:"detail"`
+}
+
+// Evaluate scores a claim against the baseline before it is observed
+func (b *Baseline) Evaluate(obs Observation, now time.Time, cfg Config) []Factor {
+	var factors []Factor
+
+	if b.Claims >= int64(cfg.MinClaims) {
+		if std := math.Sqrt(b.AmountVar); std > 0 {
+			z := (obs.Amount - b.AmountMean) / std
+			if z > cfg.AmountZ {
+				factors = append(factors, Factor{
+					Name:  "amount_zscore",
+					Value: z,
+					Detail: fmt.Sprintf("billed amount $%.2f is %.1f standard deviations above provider mean $%.2f",
+						obs.Amount, z, b.AmountMean),
+				})
+			}
+		}
+
+		var unusual []string
+		for _, code := range obs.Codes {
+			if b.CodeWeights[code] < cfg.RareCodeShare {
+				unusual = append(unusual, code)
+			}
+		}
+		if len(unusual) > 0 {
+			sort.Strings(unusual)
+			factors = append(factors, Factor{
+				Name:   "unusual_codes",
+				Value:  float64(len(unusual)),
+				Detail: fmt.Sprintf("codes rarely or never billed by this provider: %s", strings.Join(unusual, ", ")),
+			})
+		}
+
+		if em, high := emLevels(obs.Codes); em && high && b.EMVisits > 0 {
+			share := (b.HighLevelEMs + 1) / (b.EMVisits + 1)
+			if share > cfg.HighLevelEMMax {
+				factors = append(factors, Factor{
+					Name:  "high_level_em_share",
+					Value: share,
+					Detail: fmt.Sprintf("%.0f%% of provider's E/M visits are billed at the highest levels (threshold %.0f%%)",
+						share*100, cfg.HighLevelEMMax*100),
+				})
+			}
+		}
+	}
+
+	if b.Days >= cfg.MinDays {
+		todayCount := 1
+		if b.Day == now.UTC().Format("2006-01-02") {
+			todayCount += b.DayCount
+		}
+
+		usual := math.Max(b.VolumeMean, 1)
+		ratio := float64(todayCount) / usual
+		if todayCount >= cfg.MinSpikeVolume && ratio > cfg.VolumeRatio {
+			z := 0.0
+			if std := math.Sqrt(b.VolumeVar); std > 0 {
+				z = (float64(todayCount) - b.VolumeMean) / std
+			}
+			factors = append(factors, Factor{
+				Name:  "volume_spike",
+				Value: ratio,
+				Detail: fmt.Sprintf("%d claims today is %.1fx the provider's usual %.1f per day (z=%.1f)",
+					todayCount, ratio, b.VolumeMean, z),
+			})
+		}
+	}
+
+	return factors
+}
+
+// emLevels reports whether the codes include an E/M visit and whether any
+// of them is high level
+func emLevels(codes []string) (em, high bool) {
+	for _, code := range codes {
+		if evaluationAndManagement(code) {
+			em = true
+		}
+		if highLevelEM[code] {
+			high = true
+		}
+	}
+	return em, high
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/saintparish4/apx/internal/codeset"
+)
+
+// ICD10Code matches the frontend ICD10Code type
+type ICD10Code struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+// CPTCode matches the frontend CPTCode type
+type CPTCode struct {
+	Code        string  `json:"code"`
+	Description string  `json:"description"`
+	Amount      float64 `json:"amount"`
+}
+
+// MedicalCodingResponse matches the frontend MedicalCoding type
+type MedicalCodingResponse struct {
+	ICD10 []ICD10Code `json:"icd10"`
+	CPT   []CPTCode   `json:"cpt"`
+}
+
+// GetMedicalCoding handles GET /codes
+func (h *Handler) GetMedicalCoding(c *gin.Context) {
+	on, ok := parseCodeDate(c)
+	if !ok {
+		return
+	}
+
+	resp := MedicalCodingResponse{
+		ICD10: []ICD10Code{},
+		CPT:   []CPTCode{},
+	}
+
+	for _, code := range h.codeSets.Search(codeset.ICD10CM, "", on, 0) {
+		resp.ICD10 = append(resp.ICD10, ICD10Code{Code: code.Code, Description: code.Description})
+	}
+	for _, code := range h.codeSets.Search(codeset.CPT, "", on, 0) {
+		resp.CPT = append(resp.CPT, CPTCode{Code: code.Code, Description: code.Description, Amount: code.Amount})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// SearchCodes handles GET /codes/:system
+func (h *Handler) SearchCodes(c *gin.Context) {
+	system, err := codeset.ParseSystem(c.Param("system"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	on, ok := parseCodeDate(c)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit (1-500)"})
+		return
+	}
+
+	codes := h.codeSets.Search(system, c.Query("q"), on, limit)
+
+	c.JSON(http.StatusOK, gin.H{
+		"system":   system,
+		"codes":    codes,
+		"count":    len(codes),
+		"versions": h.codeSets.Versions()[system],
+	})
+}
+
+// GetCode handles GET /codes/:system/:code
+func (h *Handler) GetCode(c *gin.Context) {
+	system, err := codeset.ParseSystem(c.Param("system"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	on, ok := parseCodeDate(c)
+	if !ok {
+		return
+	}
+
+	code, err := h.codeSets.Lookup(system, c.Param("code"), on)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+			"code":  c.Param("code"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, code)
+}
+
+// parseCodeDate reads the optional ?date=YYYY-MM-DD parameter, defaulting
+// to today. It writes the error response itself when the date is invalid.
+func parseCodeDate(c *gin.Context) (time.Time, bool) {
+	value := c.Query("date")
+	if value == "" {
+		return time.Now().UTC().Truncate(24 * time.Hour), true
+	}
+
+	on, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format (YYYY-MM-DD)"})
+		return time.Time{}, false
+	}
+	return on, true
+}
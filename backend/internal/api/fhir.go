@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/saintparish4/apx/internal/domain"
+	"github.com/saintparish4/apx/internal/fhir"
+	"github.com/saintparish4/apx/internal/store"
+	"github.com/saintparish4/apx/internal/verifier"
+)
+
+// fhirContentType is the media type of FHIR JSON resources
+const fhirContentType = "application/fhir+json"
+
+// SubmitFHIRClaim handles POST /fhir/Claim. The Claim is mapped and
+// submitted as POST /claims would; the response is a queued ClaimResponse.
+func (h *Handler) SubmitFHIRClaim(c *gin.Context) {
+	data, ok := h.bindFHIRClaim(c)
+	if !ok {
+		return
+	}
+	if err := checkBilledAmount(data); err != nil {
+		fhirError(c, http.StatusBadRequest, "invalid", err.Error(), "Claim.total")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	submission, err := h.submitClaim(ctx, data)
+	if err != nil {
+		fhirError(c, http.StatusInternalServerError, "exception", err.Error(), "")
+		return
+	}
+
+	c.Render(http.StatusCreated, fhirJSON{fhir.NewClaimResponse(data, fhir.Decision{
+		Identifiers: []fhir.Identifier{
+			{Type: &fhir.CodeableConcept{Text: "IPFS CID"}, Value: submission.IPFSCID},
+			{Type: &fhir.CodeableConcept{Text: "Data hash"}, Value: submission.DataHash},
+		},
+	})})
+}
+
+// ValidateFHIRClaim handles POST /fhir/Claim/$validate. The Claim is mapped
+// and validated as POST /claims/validate would, and the result rendered as a
+// ClaimResponse.
+func (h *Handler) ValidateFHIRClaim(c *gin.Context) {
+	data, ok := h.bindFHIRClaim(c)
+	if !ok {
+		return
+	}
+
+	result := h.verifierNode.ValidateSubmission(c.Request.Context(), &verifier.Submission{ClaimData: data})
+	c.Render(http.StatusOK, fhirJSON{fhir.NewClaimResponse(data, fhir.Decision{Result: result})})
+}
+
+// GetFHIRClaimResponse handles GET /fhir/ClaimResponse/:id, rendering an
+// on-chain claim's status and this node's verdict on it
+func (h *Handler) GetFHIRClaimResponse(c *gin.Context) {
+	claimID, ok := normalizeClaimID(c.Param("id"))
+	if !ok {
+		fhirError(c, http.StatusBadRequest, "invalid", "Invalid claim ID", "")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	claim, err := h.ethService.GetClaim(ctx, common.HexToHash(claimID))
+	if err != nil {
+		log.Error().Err(err).Str("claim_id", claimID).Msg("Failed to read claim")
+		fhirError(c, http.StatusInternalServerError, "exception", "Failed to read claim", "")
+		return
+	}
+	if claim.Status == domain.ClaimStatusNone {
+		fhirError(c, http.StatusNotFound, "not-found", "Claim not found", "")
+		return
+	}
+
+	data, err := h.ipfsService.RetrieveClaimData(ctx, claim.IPFSCID)
+	if err != nil {
+		log.Error().Err(err).Str("claim_id", claimID).Msg("Failed to retrieve claim data")
+		fhirError(c, http.StatusInternalServerError, "exception", "Failed to retrieve claim data", "")
+		return
+	}
+
+	decision := fhir.Decision{ClaimID: claimID, Chain: claim}
+	verdict, err := h.verifierNode.Verdict(ctx, claimID)
+	switch {
+	case err == nil:
+		decision.Result = verdict.Result
+	case errors.Is(err, store.ErrNotFound), errors.Is(err, verifier.ErrNoVerdictStore):
+		// Other nodes may have decided the claim; the chain status stands alone
+	default:
+		log.Warn().Err(err).Str("claim_id", claimID).Msg("Failed to load verdict")
+	}
+
+	c.Render(http.StatusOK, fhirJSON{fhir.NewClaimResponse(data, decision)})
+}
+
+// bindFHIRClaim reads and maps a Claim resource. It writes an
+// OperationOutcome itself when the Claim cannot be mapped.
+func (h *Handler) bindFHIRClaim(c *gin.Context) (*domain.ClaimData, bool) {
+	var claim fhir.Claim
+	if err := c.ShouldBindJSON(&claim); err != nil {
+		fhirError(c, http.StatusBadRequest, "structure", "Invalid Claim resource: "+err.Error(), "")
+		return nil, false
+	}
+
+	data, issues := fhir.ToClaimData(&claim, h.identifiers)
+	if len(issues) > 0 {
+		c.Render(http.StatusBadRequest, fhirJSON{fhir.NewOperationOutcome(issues...)})
+		return nil, false
+	}
+	return data, true
+}
+
+// fhirError writes a single-issue OperationOutcome
+func fhirError(c *gin.Context, status int, code, message, expression string) {
+	issue := fhir.Issue{Severity: "error", Code: code, Diagnostics: message}
+	if expression != "" {
+		issue.Expression = []string{expression}
+	}
+	c.Render(status, fhirJSON{fhir.NewOperationOutcome(issue)})
+}
+
+// fhirJSON renders a resource as application/fhir+json
+type fhirJSON struct {
+	resource interface{}
+}
+
+func (r fhirJSON) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	return json.NewEncoder(w).Encode(r.resource)
+}
+
+func (r fhirJSON) WriteContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", fhirContentType)
+}
@@ -3,17 +3,22 @@ package api
 import (
 	"context"
 	"encoding/hex"
-	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
+	"github.com/saintparish4/apx/internal/codeset"
 	"github.com/saintparish4/apx/internal/domain"
 	"github.com/saintparish4/apx/internal/ethereum"
 	"github.com/saintparish4/apx/internal/ipfs"
+	"github.com/saintparish4/apx/internal/store"
 	"github.com/saintparish4/apx/internal/verifier"
 )
 
@@ -22,6 +27,8 @@ type Handler struct {
 	ethService   *ethereum.Service
 	ipfsService  *ipfs.Service
 	verifierNode *verifier.Node
+	codeSets     *codeset.Registry
+	identifiers  *domain.IdentifierHasher // keyed hashing of patient identifiers on import
 }
 
 // NewHandler createsa a new handler
@@ -29,17 +36,33 @@ func NewHandler(
 	ethService *ethereum.Service,
 	ipfsService *ipfs.Service,
 	verifierNode *verifier.Node,
+	codeSets *codeset.Registry,
+	identifiers *domain.IdentifierHasher,
 ) *Handler {
 	return &Handler{
 		ethService:   ethService,
 		ipfsService:  ipfsService,
 		verifierNode: verifierNode,
+		codeSets:     codeSets,
+		identifiers:  identifiers,
 	}
 }
 
-// SubmitClaimRequest represents the claim submission request
+// SubmitClaimRequest represents the claim submission request. The patient
+// is identified in clear and hashed by the API; claim data carrying its own
+// patient_id or patient_dob_hash is refused.
 type SubmitClaimRequest struct {
-	ClaimData domain.ClaimData `json:"claim_data" binding:"required"`
+	Patient   SubmitClaimPatient `json:"patient" binding:"required"`
+	ClaimData domain.ClaimData   `json:"claim_data" binding:"required"`
+}
+
+// SubmitClaimPatient identifies the patient. A dependent without a member ID
+// of their own is named under the subscriber's member ID, as on an 837.
+type SubmitClaimPatient struct {
+	MemberID  string `json:"member_id" binding:"required"` // the patient's or, for dependents, the subscriber's
+	BirthDate string `json:"birth_date"`                   // YYYY-MM-DD
+	LastName  string `json:"last_name"`                    // dependents only
+	FirstName string `json:"first_name"`                   // dependents only
 }
 
 // SubmitClaimResponse represents the claim submission response
@@ -47,6 +70,7 @@ type SubmitClaimResponse struct {
 	ClaimID    string `json:"claim_id"`
 	IPFSCID    string `json:"ipfs_cid"`
 	DataHash   string `json:"data_hash"`
+	AmountWei  string `json:"amount_wei"` // billed amount in the contract's 18-decimal units
 	TxHash     string `json:"tx_hash,omitempty"`
 	GatewayURL string `json:"gateway_url"`
 }
@@ -62,45 +86,101 @@ func (h *Handler) SubmitClaim(c *gin.Context) {
 		return
 	}
 
+	if err := checkBilledAmount(&req.ClaimData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.identifyPatient(&req.ClaimData, &req.Patient); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
 	defer cancel()
 
-	// Set submission timestamp
-	req.ClaimData.SubmissionTimestamp = time.Now().Unix()
+	resp, err := h.submitClaim(ctx, &req.ClaimData)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// identifyPatient sets the claim's patient hashes from the identifiers in
+// clear, hashed as 837 and FHIR imports hash them
+func (h *Handler) identifyPatient(data *domain.ClaimData, patient *SubmitClaimPatient) error {
+	if data.PatientID != "" || data.PatientDOBHash != "" {
+		return errors.New("patient_id and patient_dob_hash are set by the API; identify the patient in patient")
+	}
+	if (patient.LastName == "") != (patient.FirstName == "") {
+		return errors.New("a dependent needs both last_name and first_name")
+	}
+	if patient.BirthDate != "" {
+		if _, err := time.Parse("2006-01-02", patient.BirthDate); err != nil {
+			return fmt.Errorf("birth_date must be YYYY-MM-DD, got %q", patient.BirthDate)
+		}
+	}
+
+	id := patient.MemberID
+	if patient.LastName != "" {
+		id = domain.DependentIdentifier(patient.MemberID, patient.LastName, patient.FirstName)
+	}
+	data.PatientID = h.identifiers.Hash(id)
+	if patient.BirthDate != "" {
+		data.PatientDOBHash = h.identifiers.Hash(patient.BirthDate)
+	}
+	return nil
+}
+
+// checkBilledAmount rejects amounts the contract would: zero amounts and
+// amounts above MAX_CLAIM_AMOUNT
+func checkBilledAmount(data *domain.ClaimData) error {
+	amount := data.BilledAmount
+	if amount == nil || *amount <= 0 || *amount > domain.MaxClaimAmount {
+		return fmt.Errorf("billed_amount must be between 0.01 and %s", domain.MaxClaimAmount)
+	}
+	return nil
+}
+
+// submitClaim stores claim data in IPFS and prepares its on-chain
+// submission. Errors are safe to return to clients.
+func (h *Handler) submitClaim(ctx context.Context, data *domain.ClaimData) (*SubmitClaimResponse, error) {
+	// Set submission timestamp, and a fresh salt so the data hash cannot be
+	// confirmed by hashing guessed claim data
+	data.SubmissionTimestamp = time.Now().Unix()
+	salt, err := domain.NewSalt()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to generate commitment salt")
+		return nil, errors.New("Failed to process claim data")
+	}
+	data.Salt = salt
 
 	// Store claim data in IPFS
-	ipfsCid, err := h.ipfsService.StoreClaimData(ctx, &req.ClaimData)
+	ipfsCid, err := h.ipfsService.StoreClaimData(ctx, data)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to store claim data in IPFS")
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to store claim data",
-		})
-		return
+		return nil, errors.New("Failed to store claim data")
 	}
 
-	// Calculate data hash
-	claimDataBytes, err := json.Marshal(req.ClaimData)
+	// Calculate data hash over the canonical encoding stored in IPFS
+	dataHash, _, err := ethereum.ClaimDataHash(data)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to marshal claim data")
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to process claim data",
-		})
-		return
+		log.Error().Err(err).Msg("Failed to hash claim data")
+		return nil, errors.New("Failed to process claim data")
 	}
-	dataHash := ethereum.HashClaimData(claimDataBytes)
 
 	// TODO: Submit claim to blockchain
-	// This would call claimsRegistry.submitClaim(dataHash, ipfsCid, amount)
+	// This would call claimsRegistry.submitClaim(dataHash, ipfsCid, amountWei)
 	// For now, return the prepared data
 
-	resp := SubmitClaimResponse{
+	return &SubmitClaimResponse{
 		ClaimID:    "", // Will be set after blockchain submission
 		IPFSCID:    ipfsCid,
 		DataHash:   "0x" + hex.EncodeToString(dataHash[:]),
+		AmountWei:  data.BilledAmount.Wei().String(),
 		GatewayURL: h.ipfsService.GetGatewayURL(ipfsCid),
-	}
-
-	c.JSON(http.StatusCreated, resp)
+	}, nil
 }
 
 // GetClaimRequest represents path paramaeters for claim retrieval
@@ -155,8 +235,13 @@ func (h *Handler) GetClaimData(c *gin.Context) {
 
 // ValidateClaimRequest represents the validation request
 type ValidateClaimRequest struct {
-	ClaimData *domain.ClaimData `json:"claim_data,omitempty"`
-	IPFSCID   string            `json:"ipfs_cid,omitempty"`
+	ClaimData       *domain.ClaimData `json:"claim_data,omitempty"`
+	IPFSCID         string            `json:"ipfs_cid,omitempty"`
+	ProviderAddress string            `json:"provider_address,omitempty"` // enables registered-NPI checks
+
+	// Pin a ruleset version and evaluation time to reproduce a past verdict
+	RulesetVersion string     `json:"ruleset_version,omitempty"`
+	AsOf           *time.Time `json:"as_of,omitempty"`
 }
 
 // ValidateClaim handles POST /claims/validate
@@ -167,11 +252,24 @@ func (h *Handler) ValidateClaim(c *gin.Context) {
 		return
 	}
 
+	if req.ProviderAddress != "" && !common.IsHexAddress(req.ProviderAddress) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid provider address"})
+		return
+	}
+
 	var result *domain.ValidationResult
 
+	if req.RulesetVersion != "" || req.AsOf != nil {
+		h.validatePinned(c, &req)
+		return
+	}
+
 	if req.ClaimData != nil {
 		// Validate provided claim data directly
-		result = h.verifierNode.ValidateClaim(req.ClaimData)
+		result = h.verifierNode.ValidateSubmission(c.Request.Context(), &verifier.Submission{
+			ClaimData: req.ClaimData,
+			Provider:  common.HexToAddress(req.ProviderAddress),
+		})
 	} else if req.IPFSCID != "" {
 		// Retrieve and validate from IPFS
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
@@ -202,6 +300,200 @@ func (h *Handler) ValidateClaim(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// validatePinned validates a claim under a pinned ruleset version and clock
+func (h *Handler) validatePinned(c *gin.Context, req *ValidateClaimRequest) {
+	claimData := req.ClaimData
+	if claimData == nil {
+		if req.IPFSCID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Either claim_data or ipfs_cid must be provided",
+			})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+		defer cancel()
+
+		var err error
+		claimData, err = h.ipfsService.RetrieveClaimData(ctx, req.IPFSCID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to validate claim",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	version := req.RulesetVersion
+	if version == "" {
+		version = h.verifierNode.Ruleset().Version
+	}
+	var asOf time.Time
+	if req.AsOf != nil {
+		asOf = *req.AsOf
+	}
+
+	result, err := h.verifierNode.Replay(c.Request.Context(), &verifier.Submission{
+		ClaimData: claimData,
+		Provider:  common.HexToAddress(req.ProviderAddress),
+	}, version, asOf)
+	if errors.Is(err, verifier.ErrUnknownRuleset) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to validate claim",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// HashClaimRequest asks for the data hash of claim data, given directly or
+// by IPFS CID, optionally checking it against an expected hash
+type HashClaimRequest struct {
+	ClaimData *domain.ClaimData `json:"claim_data,omitempty"`
+	IPFSCID   string            `json:"ipfs_cid,omitempty"`
+	DataHash  string            `json:"data_hash,omitempty"`
+}
+
+// HashClaimResponse is the canonical encoding of claim data and its hash
+type HashClaimResponse struct {
+	Canonical string `json:"canonical"` // RFC 8785 JSON whose fields are the Merkle leaves
+	DataHash  string `json:"data_hash"`
+	Matches   *bool  `json:"matches,omitempty"` // set when an expected hash was given
+}
+
+// HashClaim handles POST /claims/hash
+func (h *Handler) HashClaim(c *gin.Context) {
+	var req HashClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	var expected common.Hash
+	if req.DataHash != "" {
+		raw, err := hex.DecodeString(strings.TrimPrefix(req.DataHash, "0x"))
+		if err != nil || len(raw) != common.HashLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid data hash"})
+			return
+		}
+		expected = common.BytesToHash(raw)
+	}
+
+	claimData := req.ClaimData
+	if claimData == nil {
+		if req.IPFSCID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Either claim_data or ipfs_cid must be provided",
+			})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+		defer cancel()
+
+		var err error
+		claimData, err = h.ipfsService.RetrieveClaimData(ctx, req.IPFSCID)
+		if err != nil {
+			log.Error().Err(err).Str("cid", req.IPFSCID).Msg("Failed to retrieve claim data")
+			c.JSON(http.StatusNotFound, gin.H{"error": "Claim data not found"})
+			return
+		}
+	}
+
+	dataHash, encoded, err := ethereum.ClaimDataHash(claimData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := HashClaimResponse{
+		Canonical: string(encoded),
+		DataHash:  "0x" + hex.EncodeToString(dataHash[:]),
+	}
+	if req.DataHash != "" {
+		matches := expected == dataHash
+		resp.Matches = &matches
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ReplayClaimRequest optionally overrides the ruleset a stored verdict is
+// replayed under
+type ReplayClaimRequest struct {
+	RulesetVersion string `json:"ruleset_version,omitempty"`
+}
+
+// ReplayClaim handles POST /claims/:id/replay
+func (h *Handler) ReplayClaim(c *gin.Context) {
+	claimID, ok := parseClaimID(c)
+	if !ok {
+		return
+	}
+
+	var req ReplayClaimRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	replay, err := h.verifierNode.ReplayClaim(ctx, claimID, req.RulesetVersion)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "No verdict recorded for claim"})
+		return
+	case errors.Is(err, verifier.ErrUnknownRuleset):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, verifier.ErrNoVerdictStore):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Verdict history unavailable"})
+		return
+	case err != nil:
+		log.Error().Err(err).Str("claim_id", claimID).Msg("Failed to replay claim")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to replay claim",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, replay)
+}
+
+// parseClaimID reads a claim ID path parameter, writing the error itself
+// when it is malformed
+func parseClaimID(c *gin.Context) (string, bool) {
+	claimID, ok := normalizeClaimID(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid claim ID"})
+	}
+	return claimID, ok
+}
+
+// normalizeClaimID lowercases a claim ID, reporting whether it is 0x
+// followed by 64 hex digits
+func normalizeClaimID(claimID string) (string, bool) {
+	claimID = strings.ToLower(claimID)
+	if len(claimID) != 66 || !strings.HasPrefix(claimID, "0x") {
+		return "", false
+	}
+	if _, err := hex.DecodeString(claimID[2:]); err != nil {
+		return "", false
+	}
+	return claimID, true
+}
+
 // GetProviderRequest represents path parameters for provider retrieval
 type GetProviderRequest struct {
 	Address string `uri:"address" binding:"required"`
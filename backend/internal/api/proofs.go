@@ -0,0 +1,167 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/saintparish4/apx/internal/domain"
+	"github.com/saintparish4/apx/internal/merkle"
+)
+
+// ProveClaimRequest names the fields to disclose. A field also discloses
+// everything below it, e.g. service_lines[0].
+type ProveClaimRequest struct {
+	Fields []string `json:"fields" binding:"required,min=1"`
+}
+
+// ProveClaimResponse holds inclusion proofs for the disclosed fields
+type ProveClaimResponse struct {
+	ClaimID  string          `json:"claim_id"`
+	DataHash string          `json:"data_hash"` // on-chain commitment the proofs lead to
+	Proofs   []*merkle.Proof `json:"proofs"`
+}
+
+// ProveClaim handles POST /claims/:id/proof
+func (h *Handler) ProveClaim(c *gin.Context) {
+	claimID, ok := parseClaimID(c)
+	if !ok {
+		return
+	}
+
+	var req ProveClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	claim, ok := h.onChainClaim(ctx, c, claimID)
+	if !ok {
+		return
+	}
+
+	data, err := h.ipfsService.RetrieveClaimData(ctx, claim.IPFSCID)
+	if err != nil {
+		log.Error().Err(err).Str("claim_id", claimID).Msg("Failed to retrieve claim data")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve claim data"})
+		return
+	}
+
+	tree, err := merkle.Build(data)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Claim data cannot be committed", "details": err.Error()})
+		return
+	}
+	if tree.Root() != claim.DataHash {
+		// Proofs would not verify; the claim fails data_hash_matches_chain
+		c.JSON(http.StatusConflict, gin.H{"error": "Claim data does not match on-chain data hash"})
+		return
+	}
+
+	proofs, err := tree.Prove(req.Fields...)
+	if errors.Is(err, merkle.ErrUnknownField) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build proofs", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ProveClaimResponse{
+		ClaimID:  claimID,
+		DataHash: common.Hash(claim.DataHash).Hex(),
+		Proofs:   proofs,
+	})
+}
+
+// VerifyClaimProofRequest holds proofs to check against a claim's on-chain
+// data hash
+type VerifyClaimProofRequest struct {
+	Proofs []*merkle.Proof `json:"proofs" binding:"required,min=1"`
+}
+
+// VerifyClaimProofResponse reports the outcome per proof
+type VerifyClaimProofResponse struct {
+	ClaimID  string             `json:"claim_id"`
+	DataHash string             `json:"data_hash"`
+	Valid    bool               `json:"valid"` // every proof verified
+	Results  []ProofCheckResult `json:"results"`
+}
+
+// ProofCheckResult is the outcome of one proof
+type ProofCheckResult struct {
+	Path  string `json:"path"`
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+// VerifyClaimProof handles POST /claims/:id/proof/verify
+func (h *Handler) VerifyClaimProof(c *gin.Context) {
+	claimID, ok := parseClaimID(c)
+	if !ok {
+		return
+	}
+
+	var req VerifyClaimProofRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	for i, proof := range req.Proofs {
+		if proof == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("proofs[%d] is null", i)})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	claim, ok := h.onChainClaim(ctx, c, claimID)
+	if !ok {
+		return
+	}
+
+	resp := VerifyClaimProofResponse{
+		ClaimID:  claimID,
+		DataHash: common.Hash(claim.DataHash).Hex(),
+		Valid:    true,
+		Results:  make([]ProofCheckResult, 0, len(req.Proofs)),
+	}
+	for _, proof := range req.Proofs {
+		result := ProofCheckResult{Path: proof.Path, Valid: true}
+		if err := proof.Verify(claim.DataHash); err != nil {
+			result.Valid = false
+			result.Error = err.Error()
+			resp.Valid = false
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// onChainClaim reads a claim from the registry, writing the error itself
+// when it cannot be read or does not exist
+func (h *Handler) onChainClaim(ctx context.Context, c *gin.Context, claimID string) (*domain.Claim, bool) {
+	claim, err := h.ethService.GetClaim(ctx, common.HexToHash(claimID))
+	if err != nil {
+		log.Error().Err(err).Str("claim_id", claimID).Msg("Failed to read claim")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read claim"})
+		return nil, false
+	}
+	if claim.Status == domain.ClaimStatusNone {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Claim not found"})
+		return nil, false
+	}
+	return claim, true
+}
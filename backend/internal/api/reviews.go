@@ -0,0 +1,168 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/saintparish4/apx/internal/domain"
+	"github.com/saintparish4/apx/internal/store"
+	"github.com/saintparish4/apx/internal/verifier"
+)
+
+// ListReviewsRequest represents query parameters for listing reviews
+type ListReviewsRequest struct {
+	Status string `form:"status" binding:"omitempty,oneof=pending claimed decided submitting submitted expired all"`
+	Offset int    `form:"offset" binding:"min=0"`
+	Limit  int    `form:"limit" binding:"min=1,max=100"`
+}
+
+// ClaimReviewRequest assigns a review to a reviewer
+type ClaimReviewRequest struct {
+	Reviewer string `json:"reviewer" binding:"required"`
+}
+
+// AnnotateReviewRequest adds a note to a review
+type AnnotateReviewRequest struct {
+	Reviewer string `json:"reviewer" binding:"required"`
+	Note     string `json:"note" binding:"required"`
+}
+
+// DecideReviewRequest records a reviewer's decision. The reason becomes the
+// public on-chain vote reason; annotations stay off-chain.
+type DecideReviewRequest struct {
+	Reviewer string `json:"reviewer" binding:"required"`
+	Approved *bool  `json:"approved" binding:"required"`
+	Reason   string `json:"reason" binding:"required"`
+}
+
+// ListReviews handles GET /reviews
+func (h *Handler) ListReviews(c *gin.Context) {
+	var req ListReviewsRequest
+	req.Status = string(domain.ReviewPending)
+	req.Limit = 20 // Default
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters"})
+		return
+	}
+	if req.Status == "all" {
+		req.Status = ""
+	}
+
+	reviews, err := h.verifierNode.ListReviews(c.Request.Context(), domain.ReviewStatus(req.Status), req.Limit, req.Offset)
+	if err != nil {
+		reviewError(c, "", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reviews": reviews,
+		"offset":  req.Offset,
+		"limit":   req.Limit,
+	})
+}
+
+// GetReview handles GET /reviews/:id
+func (h *Handler) GetReview(c *gin.Context) {
+	claimID, ok := parseClaimID(c)
+	if !ok {
+		return
+	}
+
+	review, err := h.verifierNode.GetReview(c.Request.Context(), claimID)
+	if err != nil {
+		reviewError(c, claimID, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// ClaimReview handles POST /reviews/:id/claim
+func (h *Handler) ClaimReview(c *gin.Context) {
+	claimID, ok := parseClaimID(c)
+	if !ok {
+		return
+	}
+
+	var req ClaimReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+
+	review, err := h.verifierNode.ClaimReview(c.Request.Context(), claimID, req.Reviewer)
+	if err != nil {
+		reviewError(c, claimID, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// AnnotateReview handles POST /reviews/:id/notes
+func (h *Handler) AnnotateReview(c *gin.Context) {
+	claimID, ok := parseClaimID(c)
+	if !ok {
+		return
+	}
+
+	var req AnnotateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+
+	review, err := h.verifierNode.AnnotateReview(c.Request.Context(), claimID, req.Reviewer, req.Note)
+	if err != nil {
+		reviewError(c, claimID, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// DecideReview handles POST /reviews/:id/decision
+func (h *Handler) DecideReview(c *gin.Context) {
+	claimID, ok := parseClaimID(c)
+	if !ok {
+		return
+	}
+
+	var req DecideReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+
+	review, err := h.verifierNode.DecideReview(c.Request.Context(), claimID, req.Reviewer, *req.Approved, req.Reason)
+	if err != nil {
+		reviewError(c, claimID, err)
+		return
+	}
+
+	// Decided but not yet on-chain: the node keeps retrying the vote
+	status := http.StatusOK
+	if review.Status != domain.ReviewSubmitted {
+		status = http.StatusAccepted
+	}
+	c.JSON(status, review)
+}
+
+// reviewError maps review queue errors to HTTP responses
+func reviewError(c *gin.Context, claimID string, err error) {
+	switch {
+	case errors.Is(err, verifier.ErrReasonTooLong):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+	case errors.Is(err, verifier.ErrReviewClosed), errors.Is(err, verifier.ErrReviewTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, verifier.ErrNoReviewQueue):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Review queue unavailable"})
+	default:
+		log.Error().Err(err).Str("claim_id", claimID).Msg("Review operation failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Review operation failed"})
+	}
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/saintparish4/apx/internal/domain"
+	"github.com/saintparish4/apx/internal/verifier"
+	"github.com/saintparish4/apx/internal/x12"
+)
+
+// maxX12Size bounds an uploaded 837 interchange
+const maxX12Size = 10 * 1024 * 1024
+
+// maxX12SubmitClaims bounds the claims one 837 may submit. Each is stored in
+// IPFS and submitted on-chain in turn within the request.
+const maxX12SubmitClaims = 50
+
+// X12ClaimResult is the outcome for one claim of an 837 file. Claims with
+// parse errors are neither validated nor submitted.
+type X12ClaimResult struct {
+	Transaction          string                   `json:"transaction"`
+	PatientControlNumber string                   `json:"patient_control_number"`
+	Errors               []*x12.SegmentError      `json:"errors,omitempty"`
+	Validation           *domain.ValidationResult `json:"validation,omitempty"`
+	Submission           *SubmitClaimResponse     `json:"submission,omitempty"`
+	SubmitError          string                   `json:"submit_error,omitempty"`
+}
+
+// X12Response summarizes an 837 file
+type X12Response struct {
+	Mode      string              `json:"mode"`
+	Claims    []X12ClaimResult    `json:"claims"`
+	Errors    []*x12.SegmentError `json:"errors,omitempty"` // problems outside any claim
+	Total     int                 `json:"total"`
+	Rejected  int                 `json:"rejected"` // claims with parse errors
+	Valid     int                 `json:"valid,omitempty"`
+	Submitted int                 `json:"submitted,omitempty"`
+}
+
+// SubmitX12 handles POST /claims/x12. The body is an 837P or 837I
+// interchange; mode=validate (the default) runs every claim through the
+// verifier and mode=submit stores and submits them.
+func (h *Handler) SubmitX12(c *gin.Context) {
+	mode := c.DefaultQuery("mode", "validate")
+	if mode != "validate" && mode != "submit" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode must be validate or submit"})
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxX12Size))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File too large (max 10MB)"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return
+	}
+
+	batch, err := x12.Parse837(string(body), h.identifiers)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid X12 interchange",
+			"details": err.Error(),
+		})
+		return
+	}
+	if mode == "submit" && len(batch.Claims) > maxX12SubmitClaims {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": fmt.Sprintf("Too many claims to submit (%d, max %d); split the file", len(batch.Claims), maxX12SubmitClaims),
+		})
+		return
+	}
+
+	resp := X12Response{
+		Mode:   mode,
+		Claims: make([]X12ClaimResult, 0, len(batch.Claims)),
+		Errors: batch.Errors,
+		Total:  len(batch.Claims),
+	}
+
+	for _, claim := range batch.Claims {
+		result := X12ClaimResult{
+			Transaction:          claim.Transaction,
+			PatientControlNumber: claim.PatientControlNumber,
+			Errors:               claim.Errors,
+		}
+		if len(claim.Errors) > 0 {
+			resp.Rejected++
+			resp.Claims = append(resp.Claims, result)
+			continue
+		}
+
+		switch mode {
+		case "validate":
+			result.Validation = h.verifierNode.ValidateSubmission(c.Request.Context(), &verifier.Submission{
+				ClaimData: claim.Data,
+			})
+			if result.Validation != nil && result.Validation.Valid {
+				resp.Valid++
+			}
+		case "submit":
+			if err := checkBilledAmount(claim.Data); err != nil {
+				result.SubmitError = err.Error()
+				break
+			}
+			ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+			result.Submission, err = h.submitClaim(ctx, claim.Data)
+			cancel()
+			if err != nil {
+				result.SubmitError = err.Error()
+				break
+			}
+			resp.Submitted++
+		}
+
+		resp.Claims = append(resp.Claims, result)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// RemittanceRequest selects the finalized claims an 835 covers
+type RemittanceRequest struct {
+	From string `form:"from" binding:"required"` // YYYY-MM-DD, first finalization date
+	To   string `form:"to" binding:"required"`   // YYYY-MM-DD, last finalization date
+}
+
+// GetRemittance handles GET /providers/:address/remittance. It returns an
+// X12 835 for the provider's claims approved or rejected on-chain in the
+// date range.
+func (h *Handler) GetRemittance(c *gin.Context) {
+	var uri GetProviderRequest
+	if err := c.ShouldBindUri(&uri); err != nil || !common.IsHexAddress(uri.Address) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid provider address"})
+		return
+	}
+
+	var req RemittanceRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to dates are required"})
+		return
+	}
+	from, errFrom := time.Parse("2006-01-02", req.From)
+	to, errTo := time.Parse("2006-01-02", req.To)
+	if errFrom != nil || errTo != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format (YYYY-MM-DD)"})
+		return
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must not be before from"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 120*time.Second)
+	defer cancel()
+
+	provider := common.HexToAddress(uri.Address)
+	// to covers the whole day
+	remittance, err := h.verifierNode.Remittance(ctx, provider, from, to.Add(24*time.Hour-time.Second))
+	if errors.Is(err, verifier.ErrRemittanceTooLarge) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Str("provider", provider.Hex()).Msg("Failed to build remittance")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build remittance"})
+		return
+	}
+
+	filename := fmt.Sprintf("835_%s_%s_%s.x12", provider.Hex(), from.Format("20060102"), to.Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "application/edi-x12", []byte(x12.Write835(remittance)))
+}
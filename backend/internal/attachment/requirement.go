@@ -0,0 +1,199 @@
+// Package attachment loads supporting-document requirements: which
+// procedures must be backed by which kinds of document, such as an operative
+// note for surgical CPT codes. Document kinds are X12 PWK report type codes.
+package attachment
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/saintparish4/apx/internal/refdata"
+)
+
+// ReportTypes are the X12 PWK01 report type codes accepted on claims
+var ReportTypes = map[string]string{
+	"09": "progress report",
+	"77": "support data for verification",
+	"B4": "referral form",
+	"CT": "certification",
+	"DA": "dental models",
+	"DG": "diagnostic report",
+	"DS": "discharge summary",
+	"EB": "explanation of benefits",
+	"LA": "laboratory results",
+	"NN": "nursing notes",
+	"OB": "operative note",
+	"OZ": "support data for claim",
+	"P6": "periodontal charts",
+	"PN": "physical therapy notes",
+	"PO": "prosthetics or orthotic certification",
+	"RB": "radiology films",
+	"RR": "radiology report",
+}
+
+// MediaTypes are the file formats supporting documents may use
+var MediaTypes = []string{"application/pdf", "image/jpeg", "image/png", "image/tiff", "text/plain"}
+
+// Requirement declares the documents a set of procedures must be backed by
+type Requirement struct {
+	ID            string   `json:"id"` // e.g. DOC-DEV-SURGERY
+	Title         string   `json:"title"`
+	Procedures    []string `json:"procedures"`     // codes, ranges (10004-69990) or wildcards (27*)
+	DocumentTypes []string `json:"document_types"` // report types any one of which satisfies the requirement
+	Severity      string   `json:"severity"`       // error fails the claim, warning only lowers its score
+
+	procedures refdata.Patterns
+}
+
+// AppliesTo reports whether a procedure needs the documentation
+func (r *Requirement) AppliesTo(code string) bool {
+	return r.procedures.Match(code)
+}
+
+// SatisfiedBy reports whether a document of the report type meets the
+// requirement
+func (r *Requirement) SatisfiedBy(reportType string) bool {
+	for _, accepted := range r.DocumentTypes {
+		if accepted == reportType {
+			return true
+		}
+	}
+	return false
+}
+
+// Describe names the accepted documents, e.g. "operative note or discharge
+// summary"
+func (r *Requirement) Describe() string {
+	names := make([]string, len(r.DocumentTypes))
+	for i, reportType := range r.DocumentTypes {
+		names[i] = ReportTypes[reportType]
+	}
+	return strings.Join(names, " or ")
+}
+
+// Requirements is the set of loaded document requirements
+type Requirements struct {
+	requirements []*Requirement
+	ids          []string
+}
+
+// Load reads every .json file in dir. A file holds an array of requirements.
+func Load(dir string) (*Requirements, error) {
+	rs := &Requirements{}
+
+	files, err := refdata.ListFiles(dir, ".json")
+	if errors.Is(err, os.ErrNotExist) {
+		return rs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list document requirements: %w", err)
+	}
+
+	seen := map[string]bool{}
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		var requirements []*Requirement
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&requirements); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+
+		for _, requirement := range requirements {
+			if err := requirement.compile(); err != nil {
+				return nil, fmt.Errorf("%s: requirement %s: %w", file, requirement.ID, err)
+			}
+			if seen[requirement.ID] {
+				return nil, fmt.Errorf("%s: duplicate requirement %s", file, requirement.ID)
+			}
+			seen[requirement.ID] = true
+			rs.requirements = append(rs.requirements, requirement)
+			rs.ids = append(rs.ids, requirement.ID)
+		}
+	}
+
+	return rs, nil
+}
+
+func (r *Requirement) compile() error {
+	if r.ID == "" {
+		return errors.New("requirement needs an id")
+	}
+	if r.Severity != "error" && r.Severity != "warning" {
+		return fmt.Errorf("severity must be error or warning, got %q", r.Severity)
+	}
+	if len(r.DocumentTypes) == 0 {
+		return errors.New("requirement needs at least one document type")
+	}
+	for _, reportType := range r.DocumentTypes {
+		if _, ok := ReportTypes[reportType]; !ok {
+			return fmt.Errorf("unknown report type %q", reportType)
+		}
+	}
+
+	var err error
+	if r.procedures, err = refdata.CompilePatterns(r.Procedures); err != nil {
+		return fmt.Errorf("procedures: %w", err)
+	}
+	if len(r.procedures) == 0 {
+		return errors.New("requirement needs at least one procedure")
+	}
+
+	return nil
+}
+
+// Loaded reports whether any requirements were loaded
+func (rs *Requirements) Loaded() bool {
+	return rs != nil && len(rs.requirements) > 0
+}
+
+// IDs returns the identifiers of the loaded requirements
+func (rs *Requirements) IDs() []string {
+	if rs == nil {
+		return nil
+	}
+	return append([]string(nil), rs.ids...)
+}
+
+// ForProcedure returns the requirements that apply to a procedure
+func (rs *Requirements) ForProcedure(code string) []*Requirement {
+	if rs == nil {
+		return nil
+	}
+	var matched []*Requirement
+	for _, requirement := range rs.requirements {
+		if requirement.AppliesTo(code) {
+			matched = append(matched, requirement)
+		}
+	}
+	return matched
+}
+
+// MediaType identifies the file format of a document from its content
+func MediaType(content []byte) string {
+	// net/http does not sniff TIFF, common for scanned and faxed records
+	if bytes.HasPrefix(content, []byte("II*\x00")) || bytes.HasPrefix(content, []byte("MM\x00*")) {
+		return "image/tiff"
+	}
+	mediaType, _, _ := strings.Cut(http.DetectContentType(content), ";")
+	return mediaType
+}
+
+// AllowedMediaType reports whether documents may use a file format
+func AllowedMediaType(mediaType string) bool {
+	for _, allowed := range MediaTypes {
+		if allowed == mediaType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,211 @@
+// Package canonical implements the JSON Canonicalization Scheme (RFC 8785).
+// Canonical JSON has no insignificant whitespace, object members sorted by
+// the UTF-16 code units of their names, numbers in ECMAScript form and
+// strings with minimal escaping, so any JCS implementation produces the
+// same bytes for the same data.
+package canonical
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Marshal encodes v as JSON and canonicalizes it
+func Marshal(v interface{}) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return Transform(raw)
+}
+
+// Transform canonicalizes a JSON document. Documents that are not I-JSON
+// (RFC 7493) because an object repeats a member name are rejected, as
+// members could not be ordered unambiguously.
+func Transform(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	value, err := decode(decoder)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("canonical: trailing data after JSON value")
+	}
+
+	var buf bytes.Buffer
+	if err := encode(&buf, value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decode reads the next value, keeping numbers as json.Number and refusing
+// duplicate member names
+func decode(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		object := map[string]interface{}{}
+		for decoder.More() {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, ok := token.(string)
+			if !ok {
+				return nil, fmt.Errorf("canonical: object key %v is not a string", token)
+			}
+			if _, duplicate := object[key]; duplicate {
+				return nil, fmt.Errorf("canonical: duplicate member name %q", key)
+			}
+			if object[key], err = decode(decoder); err != nil {
+				return nil, err
+			}
+		}
+		_, err := decoder.Token() // }
+		return object, err
+	case json.Delim('['):
+		array := []interface{}{}
+		for decoder.More() {
+			elem, err := decode(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, elem)
+		}
+		_, err := decoder.Token() // ]
+		return array, err
+	}
+	return token, nil
+}
+
+func encode(buf *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case json.Number:
+		return encodeNumber(buf, v)
+	case string:
+		return encodeString(buf, v)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, elem := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := encode(buf, elem); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool { return lessUTF16(keys[i], keys[j]) })
+
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := encodeString(buf, key); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := encode(buf, v[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("canonical: unexpected %T", value)
+	}
+	return nil
+}
+
+// encodeNumber writes a number as ECMAScript's Number.prototype.toString
+// would. Numbers are IEEE 754 doubles, so integers beyond 2^53 lose
+// precision; send those as strings.
+func encodeNumber(buf *bytes.Buffer, n json.Number) error {
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil || math.IsInf(f, 0) {
+		return fmt.Errorf("canonical: number %s is out of range", n)
+	}
+	if f == 0 {
+		buf.WriteByte('0') // also -0
+		return nil
+	}
+
+	// encoding/json formats floats as ES6 does
+	out, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	buf.Write(out)
+	return nil
+}
+
+// encodeString writes a string escaping only quotes, backslashes and
+// control characters
+func encodeString(buf *bytes.Buffer, s string) error {
+	if !utf8.ValidString(s) {
+		return fmt.Errorf("canonical: string %q is not valid UTF-8", s)
+	}
+
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(buf, `\u%04x`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+	return nil
+}
+
+// lessUTF16 orders strings by their UTF-16 code units, as JCS requires
+func lessUTF16(a, b string) bool {
+	ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
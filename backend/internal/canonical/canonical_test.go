@@ -0,0 +1,146 @@
+package canonical
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Number vectors from RFC 8785 appendix B, as IEEE 754 bit patterns
+func TestTransformNumbers(t *testing.T) {
+	tests := []struct {
+		bits uint64
+		want string
+	}{
+		{0x0000000000000000, "0"},
+		{0x8000000000000000, "0"},
+		{0x0000000000000001, "5e-324"},
+		{0x8000000000000001, "-5e-324"},
+		{0x7fefffffffffffff, "1.7976931348623157e+308"},
+		{0xffefffffffffffff, "-1.7976931348623157e+308"},
+		{0x4340000000000000, "9007199254740992"},
+		{0xc340000000000000, "-9007199254740992"},
+		{0x4430000000000000, "295147905179352830000"},
+		{0x44b52d02c7e14af5, "9.999999999999997e+22"},
+		{0x44b52d02c7e14af6, "1e+23"},
+		{0x44b52d02c7e14af7, "1.0000000000000001e+23"},
+		{0x444b1ae4d6e2ef4e, "999999999999999700000"},
+		{0x444b1ae4d6e2ef4f, "999999999999999900000"},
+		{0x444b1ae4d6e2ef50, "1e+21"},
+		{0x3eb0c6f7a0b5ed8c, "9.999999999999997e-7"},
+		{0x3eb0c6f7a0b5ed8d, "0.000001"},
+		{0x41b3de4355555553, "333333333.3333332"},
+		{0x41b3de4355555554, "333333333.33333325"},
+		{0x41b3de4355555555, "333333333.3333333"},
+		{0x41b3de4355555556, "333333333.3333334"},
+		{0x41b3de4355555557, "333333333.33333343"},
+		{0xbecbf647612f3696, "-0.0000033333333333333333"},
+		{0x43143ff3c1cb0959, "1424953923781206.2"},
+	}
+
+	for _, tt := range tests {
+		input := strconv.FormatFloat(math.Float64frombits(tt.bits), 'g', -1, 64)
+		got, err := Transform([]byte(input))
+		if err != nil {
+			t.Errorf("%016x: %v", tt.bits, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%016x: got %s, want %s", tt.bits, got, tt.want)
+		}
+	}
+}
+
+func TestTransformNumberOutOfRange(t *testing.T) {
+	if _, err := Transform([]byte("1e400")); err == nil {
+		t.Error("expected an error for a number beyond float64")
+	}
+}
+
+// The example of RFC 8785 section 3.2.2
+func TestTransformExample(t *testing.T) {
+	input := `{
+		"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+		"string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+		"literals": [null, true, false]
+	}`
+	want := `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`
+
+	got, err := Transform([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+// The sorting example of RFC 8785 section 3.2.3: members are ordered by
+// UTF-16 code units, so the emoji's surrogate pair sorts before U+FB33
+func TestTransformKeyOrder(t *testing.T) {
+	input := `{
+		"€": "Euro Sign",
+		"\r": "Carriage Return",
+		"דּ": "Hebrew Letter Dalet With Dagesh",
+		"1": "One",
+		"😀": "Emoji: Grinning Face",
+		"\u0080": "Control",
+		"ö": "Latin Small Letter O With Diaeresis"
+	}`
+	want := `{"\r":"Carriage Return","1":"One",` +
+		"\"\u0080\":\"Control\",\"ö\":\"Latin Small Letter O With Diaeresis\",\"€\":\"Euro Sign\"," +
+		"\"\U0001F600\":\"Emoji: Grinning Face\",\"דּ\":\"Hebrew Letter Dalet With Dagesh\"}"
+
+	got, err := Transform([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestTransformRejectsDuplicateKeys(t *testing.T) {
+	tests := []string{
+		`{"a":1,"a":2}`,
+		`{"a":1,"a":1}`,
+		`{"a":1,"\u0061":2}`,
+		`{"outer":{"b":null,"b":null}}`,
+		`[{"c":true},{"d":1,"d":2}]`,
+	}
+
+	for _, input := range tests {
+		_, err := Transform([]byte(input))
+		if err == nil || !strings.Contains(err.Error(), "duplicate") {
+			t.Errorf("%s: expected a duplicate member error, got %v", input, err)
+		}
+	}
+}
+
+func TestTransformRejectsInvalidDocuments(t *testing.T) {
+	tests := []string{
+		``,
+		`{"a":1} {"b":2}`,
+		`{"a":1}]`,
+		`{"a" 1}`,
+		`{1:2}`,
+		`[1,]`,
+	}
+
+	for _, input := range tests {
+		if _, err := Transform([]byte(input)); err == nil {
+			t.Errorf("%q: expected an error", input)
+		}
+	}
+}
+
+func TestTransformKeepsRepeatedKeysInSeparateObjects(t *testing.T) {
+	got, err := Transform([]byte(`[{"a":2,"b":1},{"a":1}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[{"a":2,"b":1},{"a":1}]`; string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
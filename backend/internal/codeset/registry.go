@@ -0,0 +1,211 @@
+// Package codeset loads versioned medical code sets (CPT, HCPCS Level II,
+// CDT, ICD-10-CM, CMS place of service) from disk and answers existence and
+// effective-date lookups.
+package codeset
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/saintparish4/apx/internal/refdata"
+)
+
+// System identifies a code system
+type System string
+
+const (
+	CPT     System = "cpt"
+	HCPCS   System = "hcpcs"
+	CDT     System = "cdt"
+	ICD10CM System = "icd10cm"
+	POS     System = "pos"
+)
+
+// Systems lists the code systems the registry knows how to load
+var Systems = []System{CPT, HCPCS, CDT, ICD10CM, POS}
+
+// ParseSystem converts a path or query value into a System
+func ParseSystem(value string) (System, error) {
+	switch strings.ToLower(strings.ReplaceAll(value, "-", "")) {
+	case "cpt":
+		return CPT, nil
+	case "hcpcs":
+		return HCPCS, nil
+	case "cdt":
+		return CDT, nil
+	case "icd10", "icd10cm":
+		return ICD10CM, nil
+	case "pos":
+		return POS, nil
+	default:
+		return "", fmt.Errorf("unknown code system: %s", value)
+	}
+}
+
+var (
+	// ErrUnknownCode is returned when a code does not exist in any loaded version
+	ErrUnknownCode = errors.New("code not found in code set")
+	// ErrNotEffective is returned when a code exists but is not in force on the date
+	ErrNotEffective = errors.New("code not effective on date")
+)
+
+// Code is a single entry of a code set version
+type Code struct {
+	System      System         `json:"system"`
+	Code        string         `json:"code"`
+	Description string         `json:"description"`
+	Amount      float64        `json:"amount,omitempty"` // typical charge, where the file provides one
+	Version     string         `json:"version"`
+	Period      refdata.Period `json:"period"`
+}
+
+// Registry holds every loaded version of every code system
+type Registry struct {
+	codes    map[System]map[string][]*Code
+	versions map[System][]string
+}
+
+// Load reads code set files laid out as <dir>/<system>/<version>.csv. Each
+// file needs code, description, effective_date and termination_date columns
+// and may carry an amount column.
+func Load(dir string) (*Registry, error) {
+	r := &Registry{
+		codes:    make(map[System]map[string][]*Code),
+		versions: make(map[System][]string),
+	}
+
+	for _, system := range Systems {
+		files, err := refdata.ListFiles(filepath.Join(dir, string(system)), ".csv")
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s code sets: %w", system, err)
+		}
+
+		for _, file := range files {
+			if err := r.loadFile(system, file); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return r, nil
+}
+
+func (r *Registry) loadFile(system System, path string) error {
+	table, err := refdata.ReadCSV(path, "code", "description")
+	if err != nil {
+		return err
+	}
+
+	if r.codes[system] == nil {
+		r.codes[system] = make(map[string][]*Code)
+	}
+
+	for i, row := range table.Rows {
+		period, err := refdata.ParsePeriod(row, "effective_date", "termination_date")
+		if err != nil {
+			return fmt.Errorf("%s: row %d: %w", path, i+2, err)
+		}
+
+		code := &Code{
+			System:      system,
+			Code:        strings.ToUpper(row.Get("code")),
+			Description: row.Get("description"),
+			Version:     table.Version,
+			Period:      period,
+		}
+		if amount := row.Get("amount"); amount != "" {
+			code.Amount, err = strconv.ParseFloat(amount, 64)
+			if err != nil {
+				return fmt.Errorf("%s: row %d: invalid amount: %w", path, i+2, err)
+			}
+		}
+
+		key := normalize(system, code.Code)
+		r.codes[system][key] = append(r.codes[system][key], code)
+	}
+
+	r.versions[system] = append(r.versions[system], table.Version)
+	return nil
+}
+
+// Has reports whether any version of the system was loaded
+func (r *Registry) Has(system System) bool {
+	return r != nil && len(r.codes[system]) > 0
+}
+
+// Versions returns the loaded versions of each code system
+func (r *Registry) Versions() map[System][]string {
+	if r == nil {
+		return map[System][]string{}
+	}
+	out := make(map[System][]string, len(r.versions))
+	for system, versions := range r.versions {
+		out[system] = append([]string(nil), versions...)
+	}
+	return out
+}
+
+// Lookup finds the version of a code that is in force on the given date. A
+// zero date matches any version.
+func (r *Registry) Lookup(system System, code string, on time.Time) (*Code, error) {
+	entries := r.codes[system][normalize(system, code)]
+	if len(entries) == 0 {
+		return nil, ErrUnknownCode
+	}
+
+	for _, entry := range entries {
+		if entry.Period.Contains(on) {
+			return entry, nil
+		}
+	}
+
+	return nil, ErrNotEffective
+}
+
+// Search returns codes in force on the given date whose code or description
+// contains the query, ordered by code
+func (r *Registry) Search(system System, query string, on time.Time, limit int) []*Code {
+	query = strings.ToLower(strings.TrimSpace(query))
+	results := []*Code{}
+
+	for _, entries := range r.codes[system] {
+		for _, entry := range entries {
+			if !entry.Period.Contains(on) {
+				continue
+			}
+			if query != "" &&
+				!strings.Contains(strings.ToLower(entry.Code), query) &&
+				!strings.Contains(strings.ToLower(entry.Description), query) {
+				continue
+			}
+			results = append(results, entry)
+			break // one version per code is enough for listings
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Code < results[j].Code })
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results
+}
+
+// normalize produces the lookup key for a code. ICD-10-CM codes are matched
+// with or without the decimal point.
+func normalize(system System, code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if system == ICD10CM {
+		code = strings.ReplaceAll(code, ".", "")
+	}
+	return code
+}
@@ -28,6 +28,24 @@ type Config struct {
 	IPFSAPIURL     string
 	IPFSGatewayURL string
 
+	// Reference data
+	CodeSetDir     string
+	FeeScheduleDir string
+	NCCIDir        string
+	CoverageDir    string
+	PayerDir       string
+	AttachmentDir  string
+
+	// Validation rulesets
+	RulesetDir     string
+	RulesetVersion string // active ruleset; empty uses the built-in defaults
+
+	// WebAssembly plugin rules
+	PluginDir           string
+	PluginTimeout       time.Duration // per check
+	PluginMemoryLimitMB int           // per instance
+	PluginMaxConcurrent int           // checks running at once
+
 	// JWT
 	JWTSecret     string
 	JWTExpiration time.Duration
@@ -60,6 +78,24 @@ func Load() (*Config, error) {
 		IPFSAPIURL:     getEnv("IPFS_API_URL", "https://localhost:5001"),
 		IPFSGatewayURL: getEnv("IPFS_GATEWAY_URL", "https://localhost:8080/ipfs/"),
 
+		// Reference data
+		CodeSetDir:     getEnv("CODESET_DIR", "data/codesets"),
+		FeeScheduleDir: getEnv("FEE_SCHEDULE_DIR", "data/feeschedules"),
+		NCCIDir:        getEnv("NCCI_DIR", "data/ncci"),
+		CoverageDir:    getEnv("COVERAGE_DIR", "data/coverage"),
+		PayerDir:       getEnv("PAYER_DIR", "data/payers"),
+		AttachmentDir:  getEnv("ATTACHMENT_DIR", "data/attachments"),
+
+		// Validation rulesets
+		RulesetDir:     getEnv("RULESET_DIR", "data/rulesets"),
+		RulesetVersion: getEnv("RULESET_VERSION", "2026.10"),
+
+		// WebAssembly plugin rules
+		PluginDir:           getEnv("PLUGIN_DIR", "data/plugins"),
+		PluginTimeout:       getEnvDuration("PLUGIN_TIMEOUT", 200*time.Millisecond),
+		PluginMemoryLimitMB: getEnvInt("PLUGIN_MEMORY_LIMIT_MB", 64),
+		PluginMaxConcurrent: getEnvInt("PLUGIN_MAX_CONCURRENT", 4),
+
 		// JWT
 		JWTSecret:     getEnv("JWT_SECRET", "change-me-in-production"),
 		JWTExpiration: getEnvDuration("JWT_EXPIRATION", 24*time.Hour),
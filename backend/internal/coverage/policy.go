@@ -0,0 +1,139 @@
+// Package coverage loads LCD/NCD-style coverage policies that map procedure
+// codes to the ICD-10-CM diagnoses that support their medical necessity.
+package coverage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/saintparish4/apx/internal/refdata"
+)
+
+// Policy is a single coverage determination
+type Policy struct {
+	ID              string   `json:"id"`   // e.g. L36007 or NCD 20.15
+	Type            string   `json:"type"` // LCD or NCD
+	Title           string   `json:"title"`
+	EffectiveDate   string   `json:"effective_date,omitempty"`
+	TerminationDate string   `json:"termination_date,omitempty"`
+	Procedures      []string `json:"procedures"` // codes, ranges (27000-27899) or wildcards (992*)
+	Diagnoses       []string `json:"diagnoses"`  // codes, ranges (R00.0-R00.9) or wildcards (I48.*)
+
+	period     refdata.Period
+	procedures refdata.Patterns
+	diagnoses  refdata.Patterns
+}
+
+// CoversProcedure reports whether the policy applies to a procedure code
+func (p *Policy) CoversProcedure(code string) bool {
+	return p.procedures.Match(code)
+}
+
+// SupportsDiagnosis reports whether a diagnosis establishes medical necessity
+// under the policy
+func (p *Policy) SupportsDiagnosis(code string) bool {
+	return p.diagnoses.Match(code)
+}
+
+// ActiveOn reports whether the policy is in force on a date
+func (p *Policy) ActiveOn(on time.Time) bool {
+	return p.period.Contains(on)
+}
+
+// Policies is the set of loaded coverage policies
+type Policies struct {
+	policies []*Policy
+	ids      []string
+}
+
+// Load reads every .json file in dir. A file holds one policy object or an
+// array of them.
+func Load(dir string) (*Policies, error) {
+	ps := &Policies{}
+
+	files, err := refdata.ListFiles(dir, ".json")
+	if errors.Is(err, os.ErrNotExist) {
+		return ps, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list coverage policies: %w", err)
+	}
+
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		var policies []*Policy
+		if trimmed := strings.TrimSpace(string(raw)); strings.HasPrefix(trimmed, "[") {
+			err = json.Unmarshal(raw, &policies)
+		} else {
+			var policy Policy
+			err = json.Unmarshal(raw, &policy)
+			policies = []*Policy{&policy}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+
+		for _, policy := range policies {
+			if err := policy.compile(); err != nil {
+				return nil, fmt.Errorf("%s: policy %s: %w", file, policy.ID, err)
+			}
+			ps.policies = append(ps.policies, policy)
+			ps.ids = append(ps.ids, policy.ID)
+		}
+	}
+
+	return ps, nil
+}
+
+func (p *Policy) compile() error {
+	effective, err := refdata.ParseDate(p.EffectiveDate)
+	if err != nil {
+		return fmt.Errorf("invalid effective_date: %w", err)
+	}
+	termination, err := refdata.ParseDate(p.TerminationDate)
+	if err != nil {
+		return fmt.Errorf("invalid termination_date: %w", err)
+	}
+	p.period = refdata.Period{Effective: effective, Termination: termination}
+
+	if p.procedures, err = refdata.CompilePatterns(p.Procedures); err != nil {
+		return fmt.Errorf("procedures: %w", err)
+	}
+	if p.diagnoses, err = refdata.CompilePatterns(p.Diagnoses); err != nil {
+		return fmt.Errorf("diagnoses: %w", err)
+	}
+	if len(p.procedures) == 0 || len(p.diagnoses) == 0 {
+		return fmt.Errorf("policy needs at least one procedure and one diagnosis")
+	}
+
+	return nil
+}
+
+// Loaded reports whether any policies were loaded
+func (ps *Policies) Loaded() bool {
+	return ps != nil && len(ps.policies) > 0
+}
+
+// IDs returns the identifiers of the loaded policies
+func (ps *Policies) IDs() []string {
+	return append([]string(nil), ps.ids...)
+}
+
+// ForProcedure returns the policies in force on a date that govern a procedure
+func (ps *Policies) ForProcedure(code string, on time.Time) []*Policy {
+	var matched []*Policy
+	for _, policy := range ps.policies {
+		if policy.ActiveOn(on) && policy.CoversProcedure(code) {
+			matched = append(matched, policy)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SaltSize is the length in bytes of a claim's commitment salt
+const SaltSize = 32
+
+// MinIdentifierKeySize is the shortest key an IdentifierHasher accepts
+const MinIdentifierKeySize = 32
+
+// IdentifierHasher hashes patient identifiers and dates of birth (YYYY-MM-DD)
+// for ClaimData with HMAC-SHA256, so claims for the same patient can be
+// matched without the value being stored, and without anyone lacking the key
+// being able to test guesses against the hash. Every service that submits
+// claims must share the key for duplicate detection to work.
+type IdentifierHasher struct {
+	key []byte
+}
+
+// NewIdentifierHasher creates a hasher keyed with a secret of at least
+// MinIdentifierKeySize bytes
+func NewIdentifierHasher(key []byte) (*IdentifierHasher, error) {
+	if len(key) < MinIdentifierKeySize {
+		return nil, fmt.Errorf("identifier key must be at least %d bytes, got %d", MinIdentifierKeySize, len(key))
+	}
+	return &IdentifierHasher{key: append([]byte(nil), key...)}, nil
+}
+
+// Hash returns the keyed hash of an identifier. Case and surrounding space
+// are ignored.
+func (h *IdentifierHasher) Hash(value string) string {
+	mac := hmac.New(sha256.New, h.key)
+	mac.Write([]byte(strings.ToUpper(strings.TrimSpace(value))))
+	return "0x" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// DependentIdentifier identifies a dependent, who has no member ID of their
+// own, by the subscriber's member ID and their name, as 5010 837s do
+func DependentIdentifier(subscriberID, lastName, firstName string) string {
+	return subscriberID + "|" + lastName + "|" + firstName
+}
+
+// NewSalt returns a random commitment salt for ClaimData.Salt
+func NewSalt() (string, error) {
+	salt := make([]byte, SaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	return "0x" + hex.EncodeToString(salt), nil
+}
+
+// CheckSalt reports whether a salt is SaltSize bytes of 0x-prefixed hex
+func CheckSalt(salt string) error {
+	if salt == "" {
+		return errors.New("claim data has no commitment salt")
+	}
+	raw, err := hex.DecodeString(strings.TrimPrefix(salt, "0x"))
+	if err != nil || !strings.HasPrefix(salt, "0x") || len(raw) != SaltSize {
+		return fmt.Errorf("commitment salt must be %d bytes of 0x-prefixed hex", SaltSize)
+	}
+	return nil
+}
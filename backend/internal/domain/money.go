@@ -0,0 +1,158 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in US cents. Amounts are never floating point so sums
+// and comparisons are exact.
+type Money int64
+
+// WeiPerCent scales cents to the contract's 18-decimal amounts, where
+// MAX_CLAIM_AMOUNT = 1_000_000 * 1e18 is one million dollars
+var WeiPerCent = big.NewInt(1e16)
+
+// MaxClaimAmount mirrors ClaimsRegistry.MAX_CLAIM_AMOUNT; larger claims
+// revert on-chain
+var MaxClaimAmount = MustParseMoney("1000000")
+
+// Dollars, optionally negative, with at most two decimal places and no
+// leading zeros, exponent, sign prefix, separators or currency symbol
+var moneyRegex = regexp.MustCompile(`^-?(0|[1-9]\d*)(\.\d{1,2})?$`)
+
+// ErrInvalidMoney is returned for amounts that are not plain decimal dollars
+var ErrInvalidMoney = errors.New("invalid amount")
+
+// ParseMoney parses a decimal dollar amount such as "1250", "1250.5" or
+// "1250.50". Exponents, NaN, infinities and sub-cent precision are rejected.
+func ParseMoney(s string) (Money, error) {
+	if !moneyRegex.MatchString(s) {
+		return 0, fmt.Errorf("%w %q: want dollars with at most two decimal places", ErrInvalidMoney, s)
+	}
+
+	negative := strings.HasPrefix(s, "-")
+	whole, fraction, _ := strings.Cut(strings.TrimPrefix(s, "-"), ".")
+	fraction = (fraction + "00")[:2]
+
+	dollars, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || dollars > (math.MaxInt64-99)/100 {
+		return 0, fmt.Errorf("%w %q: out of range", ErrInvalidMoney, s)
+	}
+	cents, _ := strconv.ParseInt(fraction, 10, 64)
+
+	m := Money(dollars*100 + cents)
+	if negative {
+		m = -m
+	}
+	return m, nil
+}
+
+// MustParseMoney is ParseMoney for constants; it panics on invalid input
+func MustParseMoney(s string) Money {
+	m, err := ParseMoney(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Cents returns the amount in cents
+func (m Money) Cents() int64 {
+	return int64(m)
+}
+
+// Float64 returns the amount in dollars for statistics and ratios. Never
+// round-trip it back into Money.
+func (m Money) Float64() float64 {
+	return float64(m) / 100
+}
+
+// String formats the amount as dollars with two decimal places, e.g. 1250.50
+func (m Money) String() string {
+	sign := ""
+	cents := int64(m)
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// Split divides the amount into n shares that differ by at most a cent and
+// sum exactly to the amount; earlier shares take the remainder
+func (m Money) Split(n int) []Money {
+	if n <= 0 {
+		return nil
+	}
+	shares := make([]Money, n)
+	base, remainder := int64(m)/int64(n), int64(m)%int64(n)
+	step := Money(1)
+	if remainder < 0 {
+		step, remainder = -1, -remainder
+	}
+	for i := range shares {
+		shares[i] = Money(base)
+		if int64(i) < remainder {
+			shares[i] += step
+		}
+	}
+	return shares
+}
+
+// Wei returns the amount as the contract's 18-decimal fixed-point value
+func (m Money) Wei() *big.Int {
+	return new(big.Int).Mul(big.NewInt(int64(m)), WeiPerCent)
+}
+
+// MoneyFromWei converts an on-chain amount back to cents. Amounts with
+// sub-cent precision cannot come from a Money and are rejected.
+func MoneyFromWei(wei *big.Int) (Money, error) {
+	if wei == nil {
+		return 0, fmt.Errorf("%w: missing on-chain amount", ErrInvalidMoney)
+	}
+	cents, remainder := new(big.Int).QuoRem(wei, WeiPerCent, new(big.Int))
+	if remainder.Sign() != 0 {
+		return 0, fmt.Errorf("%w: on-chain amount %s has sub-cent precision", ErrInvalidMoney, wei)
+	}
+	if !cents.IsInt64() {
+		return 0, fmt.Errorf("%w: on-chain amount %s out of range", ErrInvalidMoney, wei)
+	}
+	return Money(cents.Int64()), nil
+}
+
+// MarshalJSON encodes the amount as a string, e.g. "1250.50", so JSON
+// clients never see it as a float
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON accepts a string or a bare JSON number, both held to the
+// ParseMoney grammar
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	var s string
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	} else {
+		s = string(data)
+	}
+
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
@@ -108,30 +108,88 @@ type ClaimData struct {
 	ProviderNPI string `json:"provider_npi"`
 	FacilityID  string `json:"facility_id,omitempty"`
 
+	// Payer info
+	PayerID              string `json:"payer_id,omitempty"`               // selects the payer profile
+	OriginalClaimID      string `json:"original_claim_id,omitempty"`      // corrected claims: the claim being replaced
+	PatientControlNumber string `json:"patient_control_number,omitempty"` // provider's account number, echoed on remittances
+
 	// Service Info
 	ServiceDate    string   `json:"service_date"`
 	ProcedureCodes []string `json:"procedure_codes"` // CPT codes
 	DiagnosisCodes []string `json:"diagnosis_codes"` // ICD-10 codes
 	PlaceOfService string   `json:"place_of_service"`
 
+	// Institutional claims
+	TypeOfBill    string `json:"type_of_bill,omitempty"`   // UB-04 type of bill, e.g. 0111
+	AdmissionDate string `json:"admission_date,omitempty"` // YYYY-MM-DD; inpatient stays
+	DischargeDate string `json:"discharge_date,omitempty"` // YYYY-MM-DD; inpatient stays
+	DRGCode       string `json:"drg_code,omitempty"`       // MS-DRG of an inpatient hospital stay
+
+	// Itemized services; when present they take precedence over
+	// ProcedureCodes, which may be omitted
+	ServiceLines []ServiceLine `json:"service_lines,omitempty"`
+
 	// Financial
-	BilledAmount     string `json:"billed_amount"`
-	AllowedAmount    string `json:"allowed_amount,omitempty"`
-	CopayAmount      string `json:"copay_amount,omitempty"`
-	DeductibleAmount string `json:"deductible_amount,omitempty"`
+	BilledAmount     *Money `json:"billed_amount"`
+	AllowedAmount    *Money `json:"allowed_amount,omitempty"`
+	CopayAmount      *Money `json:"copay_amount,omitempty"`
+	DeductibleAmount *Money `json:"deductible_amount,omitempty"`
 
 	// Supporting documents (IPFS CIDs)
-	SupportingDocuments []string `json:"supporting_documents,omitempty"`
+	SupportingDocuments []string     `json:"supporting_documents,omitempty"`
+	Attachments         []Attachment `json:"attachments,omitempty"` // documents with their report type
 
 	// Metadata
 	SubmissionTimestamp int64  `json:"submission_timestamp"`
-	ClaimType           string `json:"claim_type"` // professional, institutional, etc.
+	ClaimType           string `json:"claim_type"`     // professional, institutional or dental
+	Salt                string `json:"salt,omitempty"` // random per claim; makes the on-chain data hash unguessable
 
 	// Encryption metadata
 	EncryptionKeyID string   `json:"encryption_key_id,omitempty"`
 	EncryptedFields []string `json:"encrypted_fields,omitempty"`
 }
 
+// Claim types, each validated against its own profile
+const (
+	ClaimTypeProfessional  = "professional"  // CMS-1500, 837P
+	ClaimTypeInstitutional = "institutional" // UB-04, 837I
+	ClaimTypeDental        = "dental"        // ADA dental claim form, 837D
+)
+
+// ClaimTypes lists the supported claim types
+var ClaimTypes = []string{ClaimTypeProfessional, ClaimTypeInstitutional, ClaimTypeDental}
+
+// ServiceLine is one billed service on a claim
+type ServiceLine struct {
+	ProcedureCode     string   `json:"procedure_code,omitempty"`     // CPT/HCPCS/CDT code without modifiers
+	RevenueCode       string   `json:"revenue_code,omitempty"`       // institutional claims; lines may carry it alone
+	Modifiers         []string `json:"modifiers,omitempty"`          // up to four, e.g. RT, 25, 59
+	Units             int      `json:"units"`                        // units of service, at least 1
+	Charge            Money    `json:"charge"`                       // line charges sum to BilledAmount
+	DiagnosisPointers []int    `json:"diagnosis_pointers,omitempty"` // up to four 1-based indexes into DiagnosisCodes
+	Tooth             string   `json:"tooth,omitempty"`              // dental claims: 1-32, A-T or supernumerary
+	Surfaces          string   `json:"surfaces,omitempty"`           // dental claims: letters from MODBLIF
+}
+
+// Attachment is a supporting document stored on IPFS, labelled with what it
+// is so document requirements can be checked
+type Attachment struct {
+	CID        string `json:"cid"`
+	ReportType string `json:"report_type"` // X12 PWK report type, e.g. OB operative note
+}
+
+// ClaimFingerprint is the subset of a processed claim kept in the duplicate
+// index, so later submissions can be compared without decrypting old claims
+type ClaimFingerprint struct {
+	ClaimID        string    `json:"claim_id"`
+	ContentHash    string    `json:"content_hash"` // hash of the claim's clinical and financial content
+	PatientID      string    `json:"patient_id"`
+	ProviderNPI    string    `json:"provider_npi"`
+	ServiceDate    string    `json:"service_date"`
+	ProcedureCodes []string  `json:"procedure_codes"` // base codes, modifiers stripped
+	RecordedAt     time.Time `json:"recorded_at"`
+}
+
 // Verification represents a verifier's decision
 type Verification struct {
 	Verifier  common.Address `json:"verifier"`
@@ -155,10 +213,81 @@ type ClaimSubmissionResponse struct {
 
 // ValidationRequest represents the outcome of claim validation
 type ValidationResult struct {
-	Valid     bool     `json:"valid"`
-	Approved  bool     `json:"approved"`
-	Score     float64  `json:"score"`
-	Reasons   []string `json:"reasons"`
-	Warnings  []string `json:"warnings,omitempty"`
-	RiskLevel string   `json:"risk_level"` // low, medium, high
+	Valid     bool      `json:"valid"`
+	Approved  bool      `json:"approved"`
+	Score     float64   `json:"score"`
+	Reasons   []string  `json:"reasons"`            // "rule: message" per failed error rule
+	Warnings  []string  `json:"warnings,omitempty"` // "rule: message" per failed warning rule
+	Findings  []Finding `json:"findings"`
+	RiskLevel string    `json:"risk_level"` // low, medium, high
+
+	Ruleset *RulesetInfo `json:"ruleset,omitempty"`
+}
+
+// RulesetInfo identifies the rules and reference data that produced a verdict
+type RulesetInfo struct {
+	Version       string              `json:"version"`
+	Hash          string              `json:"hash"`           // covers ruleset parameters, rule definitions and reference data
+	ReferenceData map[string][]string `json:"reference_data"` // loaded versions per data set
+	EvaluatedAt   time.Time           `json:"evaluated_at"`   // clock used by date-relative rules
+}
+
+// ReviewStatus tracks a claim through the human review queue
+type ReviewStatus string
+
+const (
+	ReviewPending    ReviewStatus = "pending"    // waiting for a reviewer
+	ReviewClaimed    ReviewStatus = "claimed"    // assigned to a reviewer
+	ReviewDecided    ReviewStatus = "decided"    // decision made, vote not yet on-chain
+	ReviewSubmitting ReviewStatus = "submitting" // vote being submitted on-chain
+	ReviewSubmitted  ReviewStatus = "submitted"  // vote submitted on-chain
+	ReviewExpired    ReviewStatus = "expired"    // verification window closed without a vote
+)
+
+// Review is a claim parked for a human reviewer instead of an automatic vote
+type Review struct {
+	ClaimID  string            `json:"claim_id"`
+	IPFSCID  string            `json:"ipfs_cid"`
+	Provider common.Address    `json:"provider"`
+	Result   *ValidationResult `json:"result"` // automated validation that triggered the review
+	Status   ReviewStatus      `json:"status"`
+	Reviewer string            `json:"reviewer,omitempty"`
+	Notes    []ReviewNote      `json:"notes"`
+
+	Approved *bool  `json:"approved,omitempty"`
+	Reason   string `json:"reason,omitempty"` // submitted as the on-chain vote reason; notes stay off-chain
+
+	Deadline       time.Time  `json:"deadline"` // last moment to decide, leaving time to submit the vote
+	WindowClosesAt time.Time  `json:"window_closes_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DecidedAt      *time.Time `json:"decided_at,omitempty"`
+	VoteTxHash     string     `json:"vote_tx_hash,omitempty"`
+	SubmittedAt    *time.Time `json:"submitted_at,omitempty"`
+}
+
+// ReviewNote is a reviewer's annotation on a review
+type ReviewNote struct {
+	Author    string    `json:"author"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Verdict is the stored validation result for an on-chain claim
+type Verdict struct {
+	ClaimID  string            `json:"claim_id"`
+	IPFSCID  string            `json:"ipfs_cid"`
+	Provider common.Address    `json:"provider"`
+	Amount   *big.Int          `json:"amount,omitempty"`    // on-chain claim amount
+	DataHash *common.Hash      `json:"data_hash,omitempty"` // on-chain data hash
+	Result   *ValidationResult `json:"result"`
+}
+
+// Finding is a single rule violation located at a field of the claim
+type Finding struct {
+	Rule        string      `json:"rule"`
+	Severity    string      `json:"severity"`       // error or warning
+	Path        string      `json:"path,omitempty"` // JSON path into the claim, e.g. procedure_codes[2]; empty for claim-level findings
+	Value       interface{} `json:"value,omitempty"`
+	Message     string      `json:"message"`
+	ScoreImpact float64     `json:"score_impact"` // points deducted from the score for this finding
 }
@@ -0,0 +1,127 @@
+package ethereum
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/saintparish4/apx/internal/domain"
+)
+
+// providerClaimsPageSize is how many claim IDs are read per getProviderClaims call
+const providerClaimsPageSize = 100
+
+const claimsRegistryViewABI = `[{
+	"type": "function",
+	"name": "getClaim",
+	"stateMutability": "view",
+	"inputs": [{"name": "claimId", "type": "bytes32"}],
+	"outputs": [{
+		"name": "",
+		"type": "tuple",
+		"components": [
+			{"name": "claimId", "type": "bytes32"},
+			{"name": "provider", "type": "address"},
+			{"name": "dataHash", "type": "bytes32"},
+			{"name": "ipfsCid", "type": "string"},
+			{"name": "amount", "type": "uint256"},
+			{"name": "submittedAt", "type": "uint256"},
+			{"name": "verifiedAt", "type": "uint256"},
+			{"name": "status", "type": "uint8"},
+			{"name": "approvalsCount", "type": "uint256"},
+			{"name": "rejectionsCount", "type": "uint256"},
+			{"name": "rejectionReason", "type": "string"}
+		]
+	}]
+}, {
+	"type": "function",
+	"name": "getProviderClaims",
+	"stateMutability": "view",
+	"inputs": [
+		{"name": "provider", "type": "address"},
+		{"name": "offset", "type": "uint256"},
+		{"name": "limit", "type": "uint256"}
+	],
+	"outputs": [{"name": "", "type": "bytes32[]"}]
+}]`
+
+var claimsRegistryView = mustParseABI(claimsRegistryViewABI)
+
+// onChainClaim mirrors the ClaimsRegistry.Claim struct
+type onChainClaim struct {
+	ClaimId         [32]byte
+	Provider        common.Address
+	DataHash        [32]byte
+	IpfsCid         string
+	Amount          *big.Int
+	SubmittedAt     *big.Int
+	VerifiedAt      *big.Int
+	Status          uint8
+	ApprovalsCount  *big.Int
+	RejectionsCount *big.Int
+	RejectionReason string
+}
+
+// GetClaim reads a claim from the ClaimsRegistry
+func (s *Service) GetClaim(ctx context.Context, claimID [32]byte) (*domain.Claim, error) {
+	var out []interface{}
+	if err := s.claimsRegistryView().Call(s.GetCallOpts(ctx), &out, "getClaim", claimID); err != nil {
+		return nil, fmt.Errorf("failed to get claim: %w", err)
+	}
+	if len(out) != 1 {
+		return nil, fmt.Errorf("unexpected getClaim result length %d", len(out))
+	}
+
+	raw := abi.ConvertType(out[0], new(onChainClaim)).(*onChainClaim)
+	claim := &domain.Claim{
+		ClaimID:         raw.ClaimId,
+		Provider:        raw.Provider,
+		DataHash:        raw.DataHash,
+		IPFSCID:         raw.IpfsCid,
+		Amount:          raw.Amount,
+		SubmittedAt:     time.Unix(raw.SubmittedAt.Int64(), 0).UTC(),
+		Status:          ToClaimStatus(raw.Status),
+		ApprovalsCount:  raw.ApprovalsCount.Uint64(),
+		RejectionsCount: raw.RejectionsCount.Uint64(),
+		RejectionReason: raw.RejectionReason,
+	}
+	if raw.VerifiedAt.Sign() > 0 {
+		claim.VerifiedAt = time.Unix(raw.VerifiedAt.Int64(), 0).UTC()
+	}
+
+	return claim, nil
+}
+
+// GetProviderClaims returns the IDs of every claim a provider has submitted,
+// oldest first
+func (s *Service) GetProviderClaims(ctx context.Context, provider common.Address) ([][32]byte, error) {
+	contract := s.claimsRegistryView()
+
+	var ids [][32]byte
+	for offset := 0; ; offset += providerClaimsPageSize {
+		var out []interface{}
+		err := contract.Call(s.GetCallOpts(ctx), &out, "getProviderClaims",
+			provider, big.NewInt(int64(offset)), big.NewInt(providerClaimsPageSize))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get provider claims: %w", err)
+		}
+		if len(out) != 1 {
+			return nil, fmt.Errorf("unexpected getProviderClaims result length %d", len(out))
+		}
+
+		page := out[0].([][32]byte)
+		ids = append(ids, page...)
+		if len(page) < providerClaimsPageSize {
+			return ids, nil
+		}
+	}
+}
+
+func (s *Service) claimsRegistryView() *bind.BoundContract {
+	return bind.NewBoundContract(s.claimsRegistryAddr, claimsRegistryView, s.client, s.client, s.client)
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -16,6 +17,7 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/saintparish4/apx/internal/domain"
+	"github.com/saintparish4/apx/internal/merkle"
 )
 
 // Service handles Ethereum blockchain interactions
@@ -180,6 +182,7 @@ type ClaimEvent struct {
 	DataHash    [32]byte
 	IPFSCID     string
 	Amount      *big.Int
+	SubmittedAt time.Time
 	BlockNumber uint64
 	TxHash      common.Hash
 }
@@ -217,12 +220,39 @@ func (s *Service) parseClaimSubmitted(vLog types.Log) (*ClaimEvent, error) {
 	// Topics[2] = indexed provider
 	event.Provider = common.HexToAddress(vLog.Topics[2].Hex())
 
-	// Non-indexed data needs to be decoded from Data field
-	// For a full implementation, will use abigen-generated bindings
+	// Non-indexed fields: dataHash, ipfsCid, amount, timestamp
+	values, err := claimSubmittedData.Unpack(vLog.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode ClaimSubmitted data: %w", err)
+	}
+	if len(values) != 4 {
+		return nil, fmt.Errorf("unexpected ClaimSubmitted data length %d", len(values))
+	}
+
+	event.DataHash = values[0].([32]byte)
+	event.IPFSCID = values[1].(string)
+	event.Amount = values[2].(*big.Int)
+	event.SubmittedAt = time.Unix(values[3].(*big.Int).Int64(), 0).UTC()
 
 	return event, nil
 }
 
+// claimSubmittedData describes the non-indexed ClaimSubmitted arguments
+var claimSubmittedData = abi.Arguments{
+	{Type: mustABIType("bytes32")},
+	{Type: mustABIType("string")},
+	{Type: mustABIType("uint256")},
+	{Type: mustABIType("uint256")},
+}
+
+func mustABIType(name string) abi.Type {
+	t, err := abi.NewType(name, "", nil)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
 // GetBalance gets the ETH balance of an address
 func (s *Service) GetBalance(ctx context.Context, address common.Address) (*big.Int, error) {
 	return s.client.BalanceAt(ctx, address, nil)
@@ -268,3 +298,14 @@ func ToClaimStatus(status uint8) domain.ClaimStatus {
 func HashClaimData(data []byte) [32]byte {
 	return crypto.Keccak256Hash(data)
 }
+
+// ClaimDataHash returns the data hash committed on-chain for a claim, the
+// Merkle root of its canonical (RFC 8785) fields, along with its canonical
+// JSON encoding
+func ClaimDataHash(data *domain.ClaimData) ([32]byte, []byte, error) {
+	tree, err := merkle.Build(data)
+	if err != nil {
+		return [32]byte{}, nil, err
+	}
+	return tree.Root(), tree.Canonical(), nil
+}
@@ -0,0 +1,67 @@
+package ethereum
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// VerificationWindow mirrors ClaimsRegistry.VERIFICATION_WINDOW; votes after
+// it closes revert
+const VerificationWindow = 7 * 24 * time.Hour
+
+// MaxReasonLength caps the vote reason stored on-chain to bound gas costs
+const MaxReasonLength = 1024
+
+const claimsRegistryABI = `[{
+	"type": "function",
+	"name": "submitVerification",
+	"stateMutability": "nonpayable",
+	"inputs": [
+		{"name": "claimId", "type": "bytes32"},
+		{"name": "approved", "type": "bool"},
+		{"name": "reason", "type": "string"}
+	],
+	"outputs": []
+}]`
+
+var claimsRegistry = mustParseABI(claimsRegistryABI)
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// SubmitVerification casts this node's vote on a claim. The reason is
+// truncated to MaxReasonLength bytes.
+func (s *Service) SubmitVerification(ctx context.Context, claimID [32]byte, approved bool, reason string) (common.Hash, error) {
+	auth, err := s.GetTransactOpts(ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	if len(reason) > MaxReasonLength {
+		reason = strings.ToValidUTF8(reason[:MaxReasonLength], "")
+	}
+
+	contract := bind.NewBoundContract(s.claimsRegistryAddr, claimsRegistry, s.client, s.client, s.client)
+	tx, err := contract.Transact(auth, "submitVerification", claimID, approved, reason)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to submit verification: %w", err)
+	}
+
+	return tx.Hash(), nil
+}
+
+// CanVote reports whether the service has a signing key for votes
+func (s *Service) CanVote() bool {
+	return s != nil && s.privateKey != nil
+}
@@ -0,0 +1,129 @@
+// Package feeschedule loads Medicare PFS-style fee schedules (allowed amount
+// per procedure code, modifier and payment locality) from disk.
+package feeschedule
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/saintparish4/apx/internal/refdata"
+)
+
+// NationalLocality is used when a code has no locality-specific amount
+const NationalLocality = "00"
+
+// Entry is the allowed amount for a code in one locality and period
+type Entry struct {
+	Code          string         `json:"code"`
+	Modifier      string         `json:"modifier,omitempty"` // e.g. 26 (professional) or TC (technical)
+	Locality      string         `json:"locality"`
+	AllowedAmount float64        `json:"allowed_amount"`
+	Version       string         `json:"version"`
+	Period        refdata.Period `json:"period"`
+}
+
+// Schedule holds all loaded fee schedule versions
+type Schedule struct {
+	entries  map[string][]*Entry
+	versions []string
+}
+
+// Load reads every <version>.csv in dir. Files need code, locality and
+// allowed_amount columns and may carry modifier, effective_date and
+// termination_date columns.
+func Load(dir string) (*Schedule, error) {
+	s := &Schedule{entries: make(map[string][]*Entry)}
+
+	files, err := refdata.ListFiles(dir, ".csv")
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fee schedules: %w", err)
+	}
+
+	for _, file := range files {
+		if err := s.loadFile(file); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+func (s *Schedule) loadFile(path string) error {
+	table, err := refdata.ReadCSV(path, "code", "locality", "allowed_amount")
+	if err != nil {
+		return err
+	}
+
+	for i, row := range table.Rows {
+		period, err := refdata.ParsePeriod(row, "effective_date", "termination_date")
+		if err != nil {
+			return fmt.Errorf("%s: row %d: %w", path, i+2, err)
+		}
+
+		amount, err := strconv.ParseFloat(row.Get("allowed_amount"), 64)
+		if err != nil || amount < 0 {
+			return fmt.Errorf("%s: row %d: invalid allowed_amount %q", path, i+2, row.Get("allowed_amount"))
+		}
+
+		entry := &Entry{
+			Code:          strings.ToUpper(row.Get("code")),
+			Modifier:      strings.ToUpper(row.Get("modifier")),
+			Locality:      row.Get("locality"),
+			AllowedAmount: amount,
+			Version:       table.Version,
+			Period:        period,
+		}
+
+		key := entryKey(entry.Code, entry.Modifier, entry.Locality)
+		s.entries[key] = append(s.entries[key], entry)
+	}
+
+	s.versions = append(s.versions, table.Version)
+	return nil
+}
+
+// Loaded reports whether any fee schedule rows were loaded
+func (s *Schedule) Loaded() bool {
+	return s != nil && len(s.entries) > 0
+}
+
+// Versions returns the loaded fee schedule versions
+func (s *Schedule) Versions() []string {
+	return append([]string(nil), s.versions...)
+}
+
+// Lookup finds the allowed amount for a code on a date. It tries the code
+// with each modifier before the bare code, and the requested locality before
+// the national rate.
+func (s *Schedule) Lookup(code string, modifiers []string, locality string, on time.Time) (*Entry, bool) {
+	code = strings.ToUpper(code)
+
+	candidates := append(append([]string(nil), modifiers...), "")
+	localities := []string{locality}
+	if locality != NationalLocality {
+		localities = append(localities, NationalLocality)
+	}
+
+	for _, loc := range localities {
+		for _, modifier := range candidates {
+			for _, entry := range s.entries[entryKey(code, strings.ToUpper(modifier), loc)] {
+				if entry.Period.Contains(on) {
+					return entry, true
+				}
+			}
+		}
+	}
+
+	return nil, false
+}
+
+func entryKey(code, modifier, locality string) string {
+	return code + "|" + modifier + "|" + locality
+}
@@ -0,0 +1,569 @@
+package fhir
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/saintparish4/apx/internal/domain"
+)
+
+// Claim is a FHIR R4 Claim resource
+type Claim struct {
+	ResourceType   string           `json:"resourceType"`
+	ID             string           `json:"id,omitempty"`
+	Contained      []Resource       `json:"contained,omitempty"`
+	Identifier     []Identifier     `json:"identifier,omitempty"`
+	Status         string           `json:"status,omitempty"`
+	Type           *CodeableConcept `json:"type,omitempty"`
+	Use            string           `json:"use,omitempty"`
+	Patient        *Reference       `json:"patient,omitempty"`
+	BillablePeriod *Period          `json:"billablePeriod,omitempty"`
+	Created        string           `json:"created,omitempty"`
+	Insurer        *Reference       `json:"insurer,omitempty"`
+	Provider       *Reference       `json:"provider,omitempty"`
+	Related        []RelatedClaim   `json:"related,omitempty"`
+	Facility       *Reference       `json:"facility,omitempty"`
+	SupportingInfo []SupportingInfo `json:"supportingInfo,omitempty"`
+	Diagnosis      []ClaimDiagnosis `json:"diagnosis,omitempty"`
+	Insurance      []ClaimInsurance `json:"insurance,omitempty"`
+	Item           []ClaimItem      `json:"item,omitempty"`
+	Total          *Money           `json:"total,omitempty"`
+}
+
+// RelatedClaim is a prior or associated claim
+type RelatedClaim struct {
+	Claim        *Reference       `json:"claim,omitempty"`
+	Relationship *CodeableConcept `json:"relationship,omitempty"`
+}
+
+// SupportingInfo is additional information about a claim. The network
+// reads the typeofbill, admissionperiod and attachment categories.
+type SupportingInfo struct {
+	Sequence        int              `json:"sequence"`
+	Category        *CodeableConcept `json:"category,omitempty"`
+	Code            *CodeableConcept `json:"code,omitempty"`
+	TimingPeriod    *Period          `json:"timingPeriod,omitempty"`
+	ValueAttachment *Attachment      `json:"valueAttachment,omitempty"`
+}
+
+// ClaimDiagnosis is a diagnosis on a claim
+type ClaimDiagnosis struct {
+	Sequence                 int              `json:"sequence"`
+	DiagnosisCodeableConcept *CodeableConcept `json:"diagnosisCodeableConcept,omitempty"`
+	PackageCode              *CodeableConcept `json:"packageCode,omitempty"` // DRG
+}
+
+// ClaimInsurance is a coverage the claim is billed to
+type ClaimInsurance struct {
+	Sequence int        `json:"sequence"`
+	Focal    bool       `json:"focal"`
+	Coverage *Reference `json:"coverage,omitempty"`
+}
+
+// ClaimItem is a billed service
+type ClaimItem struct {
+	Sequence                int               `json:"sequence"`
+	DiagnosisSequence       []int             `json:"diagnosisSequence,omitempty"`
+	Revenue                 *CodeableConcept  `json:"revenue,omitempty"`
+	ProductOrService        *CodeableConcept  `json:"productOrService,omitempty"`
+	Modifier                []CodeableConcept `json:"modifier,omitempty"`
+	ServicedDate            string            `json:"servicedDate,omitempty"`
+	ServicedPeriod          *Period           `json:"servicedPeriod,omitempty"`
+	LocationCodeableConcept *CodeableConcept  `json:"locationCodeableConcept,omitempty"`
+	Quantity                *Quantity         `json:"quantity,omitempty"`
+	UnitPrice               *Money            `json:"unitPrice,omitempty"`
+	Net                     *Money            `json:"net,omitempty"`
+	BodySite                *CodeableConcept  `json:"bodySite,omitempty"` // tooth
+	SubSite                 []CodeableConcept `json:"subSite,omitempty"`  // tooth surfaces
+}
+
+// claimTypes maps FHIR claim types to the network's
+var claimTypes = map[string]string{
+	"professional":  domain.ClaimTypeProfessional,
+	"institutional": domain.ClaimTypeInstitutional,
+	"oral":          domain.ClaimTypeDental,
+}
+
+// procedureSystems are the code systems accepted for productOrService
+var procedureSystems = []string{SystemCPT, SystemHCPCS, SystemCDT}
+
+// ToClaimData maps a Claim to ClaimData. Problems are returned as issues
+// located by FHIRPath; the ClaimData is only complete when there are none.
+//
+// The patient is identified as 837 import identifies them: by their member ID
+// (identifier type MB), or, for a dependent on the focal contained Coverage,
+// by the subscriber's member ID and their name. The identifier and birth
+// date are hashed with ids and never copied into ClaimData.
+// Attachments are supportingInfo entries of category attachment whose URL is
+// ipfs://<cid>.
+func ToClaimData(claim *Claim, ids *domain.IdentifierHasher) (*domain.ClaimData, []Issue) {
+	m := &mapper{claim: claim, ids: ids, data: &domain.ClaimData{}}
+
+	if claim.ResourceType != "Claim" {
+		m.fail("invalid", "Claim", "resourceType must be Claim, got %q", claim.ResourceType)
+		return m.data, m.issues
+	}
+	if claim.Use != "" && claim.Use != "claim" {
+		m.fail("not-supported", "Claim.use", "only claims are accepted, not %s", claim.Use)
+	}
+
+	m.claimType()
+	m.identifiers()
+	m.parties()
+	m.diagnoses()
+	m.supportingInfo()
+	m.items()
+	m.serviceDates()
+	m.total()
+
+	return m.data, m.issues
+}
+
+type mapper struct {
+	claim  *Claim
+	ids    *domain.IdentifierHasher
+	data   *domain.ClaimData
+	issues []Issue
+
+	diagnosisIndex map[int]int // diagnosis sequence to 1-based position in DiagnosisCodes
+	itemDates      []string
+}
+
+func (m *mapper) fail(code, expression, format string, args ...interface{}) {
+	m.issues = append(m.issues, Issue{
+		Severity:    "error",
+		Code:        code,
+		Diagnostics: fmt.Sprintf(format, args...),
+		Expression:  []string{expression},
+	})
+}
+
+func (m *mapper) claimType() {
+	code, ok := m.claim.Type.Code(SystemClaimType)
+	if !ok {
+		m.fail("required", "Claim.type", "claim type from %s is required", SystemClaimType)
+		return
+	}
+	claimType, ok := claimTypes[code]
+	if !ok {
+		m.fail("not-supported", "Claim.type", "claim type %q is not supported; expected professional, institutional or oral", code)
+		return
+	}
+	m.data.ClaimType = claimType
+}
+
+func (m *mapper) identifiers() {
+	if len(m.claim.Identifier) > 0 {
+		m.data.PatientControlNumber = m.claim.Identifier[0].Value
+	}
+
+	for i, related := range m.claim.Related {
+		relationship, _ := related.Relationship.Code("http://terminology.hl7.org/CodeSystem/ex-relatedclaimrelationship")
+		if relationship != "prior" || related.Claim == nil {
+			continue
+		}
+		if related.Claim.Identifier == nil || related.Claim.Identifier.Value == "" {
+			m.fail("required", fmt.Sprintf("Claim.related[%d].claim.identifier", i), "prior claim must be identified by its claim ID")
+			continue
+		}
+		m.data.OriginalClaimID = related.Claim.Identifier.Value
+	}
+}
+
+func (m *mapper) parties() {
+	patient := m.resolve(m.claim.Patient, "Patient")
+	if m.claim.Patient == nil {
+		m.fail("required", "Claim.patient", "patient is required")
+	} else if id, ok := m.patientID(patient); ok {
+		m.data.PatientID = m.ids.Hash(id)
+	}
+	if patient != nil && patient.BirthDate != "" {
+		if _, err := time.Parse("2006-01-02", patient.BirthDate); err != nil {
+			m.fail("invalid", "Claim.contained.birthDate", "birth date must be YYYY-MM-DD, got %q", patient.BirthDate)
+		} else {
+			m.data.PatientDOBHash = m.ids.Hash(patient.BirthDate)
+		}
+	}
+
+	if m.claim.Provider == nil {
+		m.fail("required", "Claim.provider", "provider is required")
+	} else if npi, ok := m.npi(m.claim.Provider); ok {
+		m.data.ProviderNPI = npi
+	} else {
+		m.fail("required", "Claim.provider", "provider must be identified by NPI (%s)", SystemNPI)
+	}
+
+	if m.claim.Facility != nil {
+		if npi, ok := m.npi(m.claim.Facility); ok {
+			m.data.FacilityID = npi
+		}
+	}
+
+	if m.claim.Insurer != nil && m.claim.Insurer.Identifier != nil {
+		m.data.PayerID = m.claim.Insurer.Identifier.Value
+	}
+}
+
+// patientID returns the identifier claims for the patient are matched by:
+// their own member ID or, for a dependent, the subscriber's member ID and
+// their name
+func (m *mapper) patientID(patient *Resource) (string, bool) {
+	if patient != nil {
+		for _, identifier := range patient.Identifier {
+			if isMemberID(identifier) {
+				return identifier.Value, true
+			}
+		}
+	}
+	if ref := m.claim.Patient.Identifier; ref != nil && isMemberID(*ref) {
+		return ref.Value, true
+	}
+
+	coverage, path := m.coverage()
+	if coverage == nil {
+		m.fail("required", "Claim.patient", "patient must carry a member ID (identifier type MB), or the claim a contained Coverage")
+		return "", false
+	}
+	subscriberID := coverage.SubscriberID
+	for _, identifier := range coverage.Identifier {
+		if subscriberID == "" && isMemberID(identifier) {
+			subscriberID = identifier.Value
+		}
+	}
+	if subscriberID == "" {
+		m.fail("required", path+".subscriberId", "coverage must carry the subscriber's member ID")
+		return "", false
+	}
+
+	relationship, _ := coverage.Relationship.Code(SystemSubscriberRelationship)
+	if relationship == "" || relationship == "self" {
+		return subscriberID, true
+	}
+	if patient == nil || len(patient.Name) == 0 || patient.Name[0].Family == "" || len(patient.Name[0].Given) == 0 {
+		m.fail("required", "Claim.contained.name", "a dependent needs a family and given name in a contained Patient")
+		return "", false
+	}
+	name := patient.Name[0]
+	return domain.DependentIdentifier(subscriberID, name.Family, name.Given[0]), true
+}
+
+// coverage returns the contained Coverage of the focal insurance, or of the
+// first when none is marked focal, with its FHIRPath
+func (m *mapper) coverage() (*Resource, string) {
+	if len(m.claim.Insurance) == 0 {
+		return nil, ""
+	}
+	index := 0
+	for i, insurance := range m.claim.Insurance {
+		if insurance.Focal {
+			index = i
+			break
+		}
+	}
+	path := fmt.Sprintf("Claim.insurance[%d].coverage", index)
+	return m.resolve(m.claim.Insurance[index].Coverage, "Coverage"), path
+}
+
+// isMemberID reports whether an identifier is a member ID (v2 type MB)
+func isMemberID(identifier Identifier) bool {
+	code, _ := identifier.Type.Code(SystemIdentifierType)
+	return code == "MB" && strings.TrimSpace(identifier.Value) != ""
+}
+
+// resolve returns the contained resource a reference points to, if any
+func (m *mapper) resolve(ref *Reference, resourceType string) *Resource {
+	if ref == nil || !strings.HasPrefix(ref.Reference, "#") {
+		return nil
+	}
+	for i := range m.claim.Contained {
+		contained := &m.claim.Contained[i]
+		if contained.ID == ref.Reference[1:] && contained.ResourceType == resourceType {
+			return contained
+		}
+	}
+	return nil
+}
+
+// npi returns the NPI a reference identifies, directly or through a
+// contained Practitioner or Organization
+func (m *mapper) npi(ref *Reference) (string, bool) {
+	if ref.Identifier != nil && ref.Identifier.System == SystemNPI && ref.Identifier.Value != "" {
+		return ref.Identifier.Value, true
+	}
+	for _, resourceType := range []string{"Practitioner", "Organization", "PractitionerRole", "Location"} {
+		if contained := m.resolve(ref, resourceType); contained != nil {
+			for _, identifier := range contained.Identifier {
+				if identifier.System == SystemNPI && identifier.Value != "" {
+					return identifier.Value, true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+func (m *mapper) diagnoses() {
+	m.diagnosisIndex = map[int]int{}
+	for _, i := range bySequence(len(m.claim.Diagnosis), func(i int) int { return m.claim.Diagnosis[i].Sequence }) {
+		diagnosis := m.claim.Diagnosis[i]
+		path := fmt.Sprintf("Claim.diagnosis[%d]", i)
+
+		if drg, ok := diagnosis.PackageCode.Code(SystemMSDRG); ok {
+			m.data.DRGCode = drg
+		}
+		if diagnosis.DiagnosisCodeableConcept == nil {
+			continue
+		}
+
+		code, ok := diagnosis.DiagnosisCodeableConcept.Code(SystemICD10CM)
+		if !ok {
+			if _, icd9 := diagnosis.DiagnosisCodeableConcept.Code(SystemICD9CM); icd9 {
+				m.fail("not-supported", path+".diagnosisCodeableConcept", "ICD-9-CM diagnoses are not supported")
+			} else {
+				m.fail("code-invalid", path+".diagnosisCodeableConcept", "diagnosis must have an ICD-10-CM coding (%s)", SystemICD10CM)
+			}
+			continue
+		}
+		if _, duplicate := m.diagnosisIndex[diagnosis.Sequence]; duplicate {
+			m.fail("invalid", path+".sequence", "duplicate diagnosis sequence %d", diagnosis.Sequence)
+			continue
+		}
+
+		m.data.DiagnosisCodes = append(m.data.DiagnosisCodes, strings.ToUpper(code))
+		m.diagnosisIndex[diagnosis.Sequence] = len(m.data.DiagnosisCodes)
+	}
+}
+
+func (m *mapper) supportingInfo() {
+	for i, info := range m.claim.SupportingInfo {
+		path := fmt.Sprintf("Claim.supportingInfo[%d]", i)
+		category, _ := info.Category.Code("http://terminology.hl7.org/CodeSystem/claiminformationcategory",
+			"http://hl7.org/fhir/us/carin-bb/CodeSystem/C4BBSupportingInfoType")
+
+		switch category {
+		case "typeofbill":
+			if code, ok := info.Code.Code(SystemTypeOfBill); ok {
+				m.data.TypeOfBill = code
+			} else {
+				m.fail("code-invalid", path+".code", "type of bill must be coded in %s", SystemTypeOfBill)
+			}
+		case "admissionperiod":
+			if info.TimingPeriod == nil || info.TimingPeriod.Start == "" {
+				m.fail("required", path+".timingPeriod", "admission period needs a start")
+				continue
+			}
+			m.data.AdmissionDate = m.date(path+".timingPeriod.start", info.TimingPeriod.Start)
+			if info.TimingPeriod.End != "" {
+				m.data.DischargeDate = m.date(path+".timingPeriod.end", info.TimingPeriod.End)
+			}
+		case "attachment":
+			m.attachment(path, info)
+		}
+	}
+}
+
+func (m *mapper) attachment(path string, info SupportingInfo) {
+	if info.ValueAttachment == nil || !strings.HasPrefix(info.ValueAttachment.URL, "ipfs://") {
+		m.fail("invalid", path+".valueAttachment.url", "attachments must be referenced as ipfs://<cid>")
+		return
+	}
+	reportType := ""
+	if info.Code != nil && len(info.Code.Coding) > 0 {
+		reportType = info.Code.Coding[0].Code
+	}
+	m.data.Attachments = append(m.data.Attachments, domain.Attachment{
+		CID:        strings.TrimPrefix(info.ValueAttachment.URL, "ipfs://"),
+		ReportType: reportType,
+	})
+}
+
+func (m *mapper) items() {
+	for _, i := range bySequence(len(m.claim.Item), func(i int) int { return m.claim.Item[i].Sequence }) {
+		item := m.claim.Item[i]
+		path := fmt.Sprintf("Claim.item[%d]", i)
+		line := domain.ServiceLine{}
+
+		if revenue, ok := item.Revenue.Code(SystemRevenue); ok {
+			line.RevenueCode = revenue
+		}
+
+		if code, ok := item.ProductOrService.Code(procedureSystems...); ok {
+			line.ProcedureCode = strings.ToUpper(code)
+		} else if line.RevenueCode == "" {
+			m.fail("code-invalid", path+".productOrService", "service must have a CPT, HCPCS or CDT coding")
+			continue
+		}
+
+		for j, modifier := range item.Modifier {
+			code, ok := modifier.Code(SystemCPT, SystemHCPCS)
+			if !ok && len(modifier.Coding) > 0 {
+				code, ok = modifier.Coding[0].Code, modifier.Coding[0].Code != ""
+			}
+			if !ok {
+				m.fail("code-invalid", fmt.Sprintf("%s.modifier[%d]", path, j), "modifier has no code")
+				continue
+			}
+			line.Modifiers = append(line.Modifiers, strings.ToUpper(code))
+		}
+
+		line.Units = 1
+		if item.Quantity != nil && item.Quantity.Value != "" {
+			units, err := item.Quantity.Value.Float64()
+			if err != nil || units != math.Trunc(units) || units < 0 || units > math.MaxInt32 {
+				m.fail("invalid", path+".quantity", "quantity must be a whole number, got %s", item.Quantity.Value)
+				continue
+			}
+			line.Units = int(units)
+		}
+
+		charge, ok := m.itemCharge(path, item, line.Units)
+		if !ok {
+			continue
+		}
+		line.Charge = charge
+
+		for _, sequence := range item.DiagnosisSequence {
+			pointer, ok := m.diagnosisIndex[sequence]
+			if !ok {
+				m.fail("invalid", path+".diagnosisSequence", "no diagnosis with sequence %d", sequence)
+				continue
+			}
+			line.DiagnosisPointers = append(line.DiagnosisPointers, pointer)
+		}
+
+		if tooth, ok := item.BodySite.Code(SystemTooth); ok {
+			line.Tooth = tooth
+		}
+		for _, surface := range item.SubSite {
+			if code, ok := surface.Code(SystemSurface); ok {
+				line.Surfaces += strings.ToUpper(code)
+			}
+		}
+
+		if pos, ok := item.LocationCodeableConcept.Code(SystemPOS); ok {
+			if m.data.PlaceOfService != "" && m.data.PlaceOfService != pos {
+				m.fail("not-supported", path+".locationCodeableConcept", "items with different places of service (%s, %s) must be billed on separate claims", m.data.PlaceOfService, pos)
+			}
+			m.data.PlaceOfService = pos
+		}
+
+		switch {
+		case item.ServicedDate != "":
+			m.itemDates = append(m.itemDates, m.date(path+".servicedDate", item.ServicedDate))
+		case item.ServicedPeriod != nil && item.ServicedPeriod.Start != "":
+			m.itemDates = append(m.itemDates, m.date(path+".servicedPeriod.start", item.ServicedPeriod.Start))
+		}
+
+		m.data.ServiceLines = append(m.data.ServiceLines, line)
+	}
+}
+
+// bySequence returns the indexes of n elements ordered by sequence, so
+// elements are read in sequence order but reported at their position in the
+// resource
+func bySequence(n int, sequence func(i int) int) []int {
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return sequence(order[a]) < sequence(order[b]) })
+	return order
+}
+
+// itemCharge returns an item's net amount, or unit price times quantity
+func (m *mapper) itemCharge(path string, item ClaimItem, units int) (domain.Money, bool) {
+	if item.Net != nil {
+		return m.money(path+".net", item.Net)
+	}
+	if item.UnitPrice != nil {
+		price, ok := m.money(path+".unitPrice", item.UnitPrice)
+		return price * domain.Money(units), ok
+	}
+	m.fail("required", path+".net", "item needs a net amount or unit price")
+	return 0, false
+}
+
+// serviceDates sets the claim's date of service: the start of the billable
+// period for institutional claims, otherwise the earliest item date
+func (m *mapper) serviceDates() {
+	if m.claim.BillablePeriod != nil && m.claim.BillablePeriod.Start != "" {
+		start := m.date("Claim.billablePeriod.start", m.claim.BillablePeriod.Start)
+		if m.data.ClaimType == domain.ClaimTypeInstitutional || len(m.itemDates) == 0 {
+			m.data.ServiceDate = start
+			return
+		}
+	}
+
+	dates := []string{}
+	for _, date := range m.itemDates {
+		if date != "" {
+			dates = append(dates, date)
+		}
+	}
+	if len(dates) == 0 {
+		m.fail("required", "Claim.item.servicedDate", "claim needs a billable period or item service dates")
+		return
+	}
+	sort.Strings(dates)
+	m.data.ServiceDate = dates[0]
+}
+
+func (m *mapper) total() {
+	if m.claim.Total != nil {
+		if total, ok := m.money("Claim.total", m.claim.Total); ok {
+			m.data.BilledAmount = &total
+		}
+		return
+	}
+
+	// Without a total the items are the bill
+	if len(m.data.ServiceLines) == 0 {
+		m.fail("required", "Claim.total", "claim needs a total or priced items")
+		return
+	}
+	total := domain.Money(0)
+	for _, line := range m.data.ServiceLines {
+		total += line.Charge
+	}
+	m.data.BilledAmount = &total
+}
+
+// money parses a USD amount
+func (m *mapper) money(path string, money *Money) (domain.Money, bool) {
+	if money.Currency != "" && money.Currency != "USD" {
+		m.fail("not-supported", path+".currency", "only USD amounts are accepted, got %s", money.Currency)
+		return 0, false
+	}
+	amount, err := domain.ParseMoney(normalizeDecimal(money.Value.String()))
+	if err != nil {
+		m.fail("invalid", path+".value", "invalid amount %q: %v", money.Value.String(), err)
+		return 0, false
+	}
+	return amount, true
+}
+
+// normalizeDecimal drops trailing zeros past the cents, e.g. 150.500
+func normalizeDecimal(value string) string {
+	whole, fraction, ok := strings.Cut(value, ".")
+	if !ok {
+		return value
+	}
+	for len(fraction) > 2 && strings.HasSuffix(fraction, "0") {
+		fraction = fraction[:len(fraction)-1]
+	}
+	return whole + "." + fraction
+}
+
+// date reads a FHIR date or dateTime as YYYY-MM-DD
+func (m *mapper) date(path, value string) string {
+	if len(value) >= 10 {
+		if _, err := time.Parse("2006-01-02", value[:10]); err == nil {
+			return value[:10]
+		}
+	}
+	m.fail("invalid", path, "date must be YYYY-MM-DD, got %q", value)
+	return ""
+}
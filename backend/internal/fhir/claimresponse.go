@@ -0,0 +1,235 @@
+package fhir
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/saintparish4/apx/internal/domain"
+	"github.com/saintparish4/apx/internal/verifier"
+)
+
+// ClaimResponse is a FHIR R4 ClaimResponse resource
+type ClaimResponse struct {
+	ResourceType string           `json:"resourceType"`
+	ID           string           `json:"id,omitempty"`
+	Identifier   []Identifier     `json:"identifier,omitempty"`
+	Status       string           `json:"status"`
+	Type         *CodeableConcept `json:"type"`
+	Use          string           `json:"use"`
+	Patient      *Reference       `json:"patient"`
+	Created      string           `json:"created"`
+	Insurer      *Reference       `json:"insurer"`
+	Request      *Reference       `json:"request,omitempty"`
+	Outcome      string           `json:"outcome"` // queued, complete, error or partial
+	Disposition  string           `json:"disposition,omitempty"`
+	Item         []ResponseItem   `json:"item,omitempty"`
+	Total        []Total          `json:"total,omitempty"`
+	ProcessNote  []ProcessNote    `json:"processNote,omitempty"`
+	Error        []ResponseError  `json:"error,omitempty"`
+}
+
+// ResponseItem is the adjudication of one claim item
+type ResponseItem struct {
+	ItemSequence int            `json:"itemSequence"`
+	Adjudication []Adjudication `json:"adjudication"`
+}
+
+// Adjudication is one amount of an adjudication, such as the submitted or
+// eligible amount
+type Adjudication struct {
+	Category *CodeableConcept `json:"category"`
+	Amount   *Money           `json:"amount,omitempty"`
+}
+
+// Total is a claim-level adjudication amount
+type Total struct {
+	Category *CodeableConcept `json:"category"`
+	Amount   *Money           `json:"amount"`
+}
+
+// ProcessNote is a note to the provider
+type ProcessNote struct {
+	Number int    `json:"number"`
+	Type   string `json:"type"` // display
+	Text   string `json:"text"`
+}
+
+// ResponseError is a rule the claim failed, coded as a CARC
+type ResponseError struct {
+	ItemSequence int              `json:"itemSequence,omitempty"`
+	Code         *CodeableConcept `json:"code"`
+}
+
+// Decision is what the network knows about a claim
+type Decision struct {
+	ClaimID     string                   // on-chain claim ID; empty when the claim was only validated
+	Chain       *domain.Claim            // on-chain record; nil when only validated or not yet on-chain
+	Result      *domain.ValidationResult // this node's validation; nil when it has none
+	Identifiers []Identifier             // e.g. the IPFS CID and data hash of a submission
+}
+
+// fhirClaimTypes maps the network's claim types to FHIR's
+var fhirClaimTypes = map[string]string{
+	domain.ClaimTypeProfessional:  "professional",
+	domain.ClaimTypeInstitutional: "institutional",
+	domain.ClaimTypeDental:        "oral",
+}
+
+// serviceLineRegex matches finding paths within a service line
+var serviceLineRegex = regexp.MustCompile(`^service_lines\[(\d+)\]`)
+
+// NewClaimResponse renders the adjudication of a claim. The on-chain status
+// decides the outcome when there is one, otherwise the validation result.
+// Failed error rules become errors coded by CARC; warnings become notes.
+func NewClaimResponse(data *domain.ClaimData, d Decision) *ClaimResponse {
+	resp := &ClaimResponse{
+		ResourceType: "ClaimResponse",
+		Identifier:   d.Identifiers,
+		Status:       "active",
+		Type:         concept(SystemClaimType, fhirClaimTypes[data.ClaimType], ""),
+		Use:          "claim",
+		Patient:      &Reference{Identifier: &Identifier{Value: data.PatientID}},
+		Created:      time.Now().UTC().Format(time.RFC3339),
+		Insurer:      &Reference{Display: "APX claims network"},
+	}
+	if data.PayerID != "" {
+		resp.Insurer = &Reference{Identifier: &Identifier{Value: data.PayerID}}
+	}
+	if d.ClaimID != "" {
+		resp.ID = d.ClaimID
+		resp.Request = &Reference{Identifier: &Identifier{Value: d.ClaimID}}
+	} else if data.PatientControlNumber != "" {
+		resp.Request = &Reference{Identifier: &Identifier{Value: data.PatientControlNumber}}
+	}
+
+	approved, final := resp.decide(d)
+
+	for i, line := range data.ServiceLines {
+		resp.Item = append(resp.Item, ResponseItem{
+			ItemSequence: i + 1,
+			Adjudication: []Adjudication{{Category: adjudication("submitted"), Amount: money(line.Charge)}},
+		})
+	}
+	resp.Total = totals(data, approved, final)
+
+	if d.Result != nil {
+		for _, finding := range d.Result.Findings {
+			if finding.Severity != "error" {
+				resp.note(fmt.Sprintf("%s: %s", finding.Rule, finding.Message))
+				continue
+			}
+			reason, _ := verifier.AdjustmentReason(finding.Rule, finding.Message)
+			entry := ResponseError{Code: concept(SystemCARC, reason, "")}
+			entry.Code.Text = fmt.Sprintf("%s: %s", finding.Rule, finding.Message)
+			if match := serviceLineRegex.FindStringSubmatch(finding.Path); match != nil {
+				index, _ := strconv.Atoi(match[1])
+				entry.ItemSequence = index + 1
+			}
+			resp.Error = append(resp.Error, entry)
+		}
+	}
+
+	return resp
+}
+
+// decide sets the outcome and disposition, and reports whether the claim
+// was approved and whether that is final
+func (resp *ClaimResponse) decide(d Decision) (approved, final bool) {
+	if d.Chain != nil {
+		resp.Disposition = d.Chain.Status.String()
+		switch d.Chain.Status {
+		case domain.ClaimStatusApproved:
+			resp.Outcome = "complete"
+			return true, true
+		case domain.ClaimStatusRejected:
+			resp.Outcome = "complete"
+			if d.Chain.RejectionReason != "" {
+				resp.Disposition = "Rejected: " + d.Chain.RejectionReason
+			}
+			return false, true
+		case domain.ClaimStatusExpired:
+			resp.Outcome = "error"
+			return false, true
+		default:
+			resp.Outcome = "queued"
+			return false, false
+		}
+	}
+
+	if d.Result == nil {
+		resp.Outcome = "queued"
+		resp.Disposition = "Submitted for verification"
+		return false, false
+	}
+
+	switch {
+	case d.Result.Approved:
+		resp.Outcome = "complete"
+		resp.Disposition = fmt.Sprintf("Approved: score %.0f", d.Result.Score)
+		return true, true
+	case len(d.Result.Reasons) > 0:
+		resp.Outcome = "error"
+		resp.Disposition = fmt.Sprintf("Rejected: score %.0f", d.Result.Score)
+		return false, true
+	default:
+		// Valid but below the approval threshold: the claim needs review
+		resp.Outcome = "partial"
+		resp.Disposition = fmt.Sprintf("Needs review: score %.0f", d.Result.Score)
+		return false, false
+	}
+}
+
+// totals reports the submitted amount and, once the claim is decided, the
+// eligible amount, patient responsibility and benefit
+func totals(data *domain.ClaimData, approved, final bool) []Total {
+	if data.BilledAmount == nil {
+		return nil
+	}
+	billed := *data.BilledAmount
+	out := []Total{{Category: adjudication("submitted"), Amount: money(billed)}}
+	if !final {
+		return out
+	}
+	if !approved {
+		return append(out, Total{Category: adjudication("benefit"), Amount: money(0)})
+	}
+
+	eligible := billed
+	if data.AllowedAmount != nil && *data.AllowedAmount < eligible {
+		eligible = *data.AllowedAmount
+	}
+	out = append(out, Total{Category: adjudication("eligible"), Amount: money(eligible)})
+
+	benefit := eligible
+	for _, share := range []struct {
+		category string
+		amount   *domain.Money
+	}{{"deductible", data.DeductibleAmount}, {"copay", data.CopayAmount}} {
+		if share.amount == nil || *share.amount <= 0 {
+			continue
+		}
+		amount := *share.amount
+		if amount > benefit {
+			amount = benefit
+		}
+		out = append(out, Total{Category: adjudication(share.category), Amount: money(amount)})
+		benefit -= amount
+	}
+
+	return append(out, Total{Category: adjudication("benefit"), Amount: money(benefit)})
+}
+
+func (resp *ClaimResponse) note(text string) {
+	resp.ProcessNote = append(resp.ProcessNote, ProcessNote{Number: len(resp.ProcessNote) + 1, Type: "display", Text: text})
+}
+
+func adjudication(category string) *CodeableConcept {
+	return concept(SystemAdjudication, category, "")
+}
+
+func money(amount domain.Money) *Money {
+	return &Money{Value: json.Number(amount.String()), Currency: "USD"}
+}
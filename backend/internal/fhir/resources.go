@@ -0,0 +1,163 @@
+// Package fhir maps FHIR R4 Claim resources to domain.ClaimData and renders
+// validation results and on-chain status as FHIR R4 ClaimResponse resources.
+// Only the elements the network uses are modelled; others are ignored.
+package fhir
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Code systems
+const (
+	SystemClaimType    = "http://terminology.hl7.org/CodeSystem/claim-type"
+	SystemAdjudication = "http://terminology.hl7.org/CodeSystem/adjudication"
+	SystemNPI          = "http://hl7.org/fhir/sid/us-npi"
+	SystemICD10CM      = "http://hl7.org/fhir/sid/icd-10-cm"
+	SystemICD9CM       = "http://hl7.org/fhir/sid/icd-9-cm"
+	SystemCPT          = "http://www.ama-assn.org/go/cpt"
+	SystemHCPCS        = "https://www.cms.gov/Medicare/Coding/HCPCSReleaseCodeSets"
+	SystemCDT          = "http://www.ada.org/cdt"
+	SystemRevenue      = "https://www.nubc.org/CodeSystem/RevenueCodes"
+	SystemTypeOfBill   = "https://www.nubc.org/CodeSystem/TypeOfBill"
+	SystemPOS          = "https://www.cms.gov/Medicare/Coding/place-of-service-codes/Place_of_Service_Code_Set"
+	SystemTooth        = "http://terminology.hl7.org/CodeSystem/ex-tooth"
+	SystemSurface      = "http://terminology.hl7.org/CodeSystem/FDI-surface"
+	SystemMSDRG        = "https://www.cms.gov/Medicare/Medicare-Fee-for-Service-Payment/AcuteInpatientPPS/MS-DRG-Classifications-and-Software"
+	SystemCARC         = "https://x12.org/codes/claim-adjustment-reason-codes"
+
+	SystemIdentifierType         = "http://terminology.hl7.org/CodeSystem/v2-0203"
+	SystemSubscriberRelationship = "http://terminology.hl7.org/CodeSystem/subscriber-relationship"
+)
+
+// systemAliases are other spellings of the systems above seen in the wild
+var systemAliases = map[string]string{
+	"http://www.cms.gov/Medicare/Coding/HCPCSReleaseCodeSets":    SystemHCPCS,
+	"urn:oid:2.16.840.1.113883.6.285":                            SystemHCPCS,
+	"http://ada.org/cdt":                                         SystemCDT,
+	"urn:oid:2.16.840.1.113883.6.13":                             SystemCDT,
+	"http://www.nubc.org/CodeSystem/RevenueCodes":                SystemRevenue,
+	"urn:oid:2.16.840.1.113883.6.12":                             SystemCPT,
+	"urn:oid:2.16.840.1.113883.6.90":                             SystemICD10CM,
+	"http://terminology.hl7.org/CodeSystem/icd10CM":              SystemICD10CM,
+	"https://www.cms.gov/Medicare/Coding/place-of-service-codes": SystemPOS,
+}
+
+func canonicalSystem(system string) string {
+	if canonical, ok := systemAliases[system]; ok {
+		return canonical
+	}
+	return system
+}
+
+// Coding is a code from a code system
+type Coding struct {
+	System  string `json:"system,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+// CodeableConcept is a concept given by one or more codings and/or text
+type CodeableConcept struct {
+	Coding []Coding `json:"coding,omitempty"`
+	Text   string   `json:"text,omitempty"`
+}
+
+// Code returns the code of the first coding in one of the systems
+func (c *CodeableConcept) Code(systems ...string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	for _, coding := range c.Coding {
+		system := canonicalSystem(coding.System)
+		for _, wanted := range systems {
+			if system == wanted && strings.TrimSpace(coding.Code) != "" {
+				return strings.TrimSpace(coding.Code), true
+			}
+		}
+	}
+	return "", false
+}
+
+// concept builds a CodeableConcept with a single coding
+func concept(system, code, display string) *CodeableConcept {
+	return &CodeableConcept{Coding: []Coding{{System: system, Code: code, Display: display}}}
+}
+
+// Identifier is a business identifier
+type Identifier struct {
+	Type   *CodeableConcept `json:"type,omitempty"`
+	System string           `json:"system,omitempty"`
+	Value  string           `json:"value,omitempty"`
+}
+
+// Reference refers to another resource by literal reference or identifier
+type Reference struct {
+	Reference  string      `json:"reference,omitempty"`
+	Identifier *Identifier `json:"identifier,omitempty"`
+	Display    string      `json:"display,omitempty"`
+}
+
+// Period is a time range; either end may be open
+type Period struct {
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+// Money is an amount in a currency. The value keeps its decimal text so
+// amounts are parsed exactly.
+type Money struct {
+	Value    json.Number `json:"value,omitempty"`
+	Currency string      `json:"currency,omitempty"`
+}
+
+// Quantity is a measured amount
+type Quantity struct {
+	Value json.Number `json:"value,omitempty"`
+}
+
+// Attachment is content referenced by URL
+type Attachment struct {
+	ContentType string `json:"contentType,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Title       string `json:"title,omitempty"`
+}
+
+// HumanName is a person's name
+type HumanName struct {
+	Family string   `json:"family,omitempty"`
+	Given  []string `json:"given,omitempty"`
+}
+
+// Resource is a contained resource. Only the elements used to resolve
+// patients, coverage and providers are read.
+type Resource struct {
+	ResourceType string       `json:"resourceType"`
+	ID           string       `json:"id,omitempty"`
+	Identifier   []Identifier `json:"identifier,omitempty"`
+	Name         []HumanName  `json:"name,omitempty"`
+	BirthDate    string       `json:"birthDate,omitempty"`
+
+	// Coverage
+	SubscriberID string           `json:"subscriberId,omitempty"`
+	Relationship *CodeableConcept `json:"relationship,omitempty"`
+}
+
+// OperationOutcome reports why a request failed
+type OperationOutcome struct {
+	ResourceType string  `json:"resourceType"`
+	Issue        []Issue `json:"issue"`
+}
+
+// Issue is a single problem with a request
+type Issue struct {
+	Severity    string   `json:"severity"` // fatal, error, warning or information
+	Code        string   `json:"code"`     // e.g. invalid, required, not-supported
+	Diagnostics string   `json:"diagnostics,omitempty"`
+	Expression  []string `json:"expression,omitempty"` // FHIRPath of the offending element
+}
+
+// NewOperationOutcome wraps issues in an OperationOutcome
+func NewOperationOutcome(issues ...Issue) *OperationOutcome {
+	return &OperationOutcome{ResourceType: "OperationOutcome", Issue: issues}
+}
@@ -0,0 +1,252 @@
+package ipfs
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Envelope layout, version 1:
+//
+//	magic      "APXE"
+//	version    1 byte
+//	algorithm  1 byte
+//	key ID     1-byte length, then the ID
+//	associated 2-byte big-endian length, then the data
+//	nonce      algorithm's nonce size
+//	ciphertext with the AEAD tag
+//
+// Everything before the nonce is passed to the AEAD as associated data, so
+// the key ID and associated data cannot be altered without failing
+// decryption. Content without the magic is a legacy nonce||ciphertext blob
+// sealed with AES-256-GCM and no associated data.
+var envelopeMagic = []byte("APXE")
+
+const envelopeVersion = 1
+
+// Encryption algorithms
+const (
+	AlgorithmAES256GCM byte = 1
+)
+
+// ErrUnknownKey is returned when an envelope names a key the keyring lacks
+var ErrUnknownKey = errors.New("unknown encryption key")
+
+// Keyring holds the payload encryption keys by ID. New payloads are sealed
+// with the active key; the others remain available to open older ones.
+type Keyring struct {
+	active string
+	keys   map[string][]byte
+}
+
+// NewKeyring creates a keyring of AES-256 keys with the given active key
+func NewKeyring(active string, keys map[string][]byte) (*Keyring, error) {
+	if _, ok := keys[active]; !ok {
+		return nil, fmt.Errorf("active key %q is not in the keyring", active)
+	}
+	ring := &Keyring{active: active, keys: make(map[string][]byte, len(keys))}
+	for id, key := range keys {
+		if id == "" || len(id) > 255 || strings.ContainsAny(id, ":,") {
+			return nil, fmt.Errorf("invalid key ID %q", id)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("key %q must be 32 bytes, got %d", id, len(key))
+		}
+		ring.keys[id] = append([]byte(nil), key...)
+	}
+	return ring, nil
+}
+
+// ParseKeyring parses keys given as comma-separated id:hex pairs
+func ParseKeyring(active, spec string) (*Keyring, error) {
+	keys := make(map[string][]byte)
+	for _, entry := range strings.Split(spec, ",") {
+		id, keyHex, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			return nil, fmt.Errorf("key entry %q is not id:hex", entry)
+		}
+		key, err := hex.DecodeString(keyHex)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		if _, dup := keys[id]; dup {
+			return nil, fmt.Errorf("key %q is given twice", id)
+		}
+		keys[id] = key
+	}
+	return NewKeyring(active, keys)
+}
+
+// developmentKey is the key used when none is configured. It is public, so
+// payloads sealed with it are not protected.
+var developmentKey = []byte("12345678901234567890123456789012")
+
+// KeyringFromEnv loads the keyring from IPFS_ENCRYPTION_KEYS (id:hex pairs,
+// with IPFS_ENCRYPTION_KEY_ID naming the active key) or a single hex
+// IPFS_ENCRYPTION_KEY, falling back to the development key with a warning
+func KeyringFromEnv() (*Keyring, error) {
+	if spec := os.Getenv("IPFS_ENCRYPTION_KEYS"); spec != "" {
+		return ParseKeyring(os.Getenv("IPFS_ENCRYPTION_KEY_ID"), spec)
+	}
+
+	if keyHex := os.Getenv("IPFS_ENCRYPTION_KEY"); keyHex != "" {
+		key, err := hex.DecodeString(keyHex)
+		if err != nil {
+			return nil, fmt.Errorf("IPFS_ENCRYPTION_KEY: %w", err)
+		}
+		return NewKeyring("default", map[string][]byte{"default": key})
+	}
+
+	// For development only (DO NOT USE IN PRODUCTION)
+	log.Warn().Msg("Using default encryption key - NOT SECURE FOR PRODUCTION")
+	return NewKeyring("default", map[string][]byte{"default": developmentKey})
+}
+
+// ActiveKeyID returns the ID of the key new payloads are sealed with
+func (k *Keyring) ActiveKeyID() string {
+	return k.active
+}
+
+// seal encrypts plaintext into an envelope under the active key
+func (k *Keyring) seal(plaintext, associated []byte) ([]byte, error) {
+	if len(associated) > 0xffff {
+		return nil, errors.New("associated data too long")
+	}
+
+	gcm, err := newGCM(k.keys[k.active])
+	if err != nil {
+		return nil, err
+	}
+
+	header := bytes.NewBuffer(append([]byte(nil), envelopeMagic...))
+	header.WriteByte(envelopeVersion)
+	header.WriteByte(AlgorithmAES256GCM)
+	header.WriteByte(byte(len(k.active)))
+	header.WriteString(k.active)
+	binary.Write(header, binary.BigEndian, uint16(len(associated)))
+	header.Write(associated)
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	ad := header.Bytes()
+	out := append(append([]byte(nil), ad...), nonce...)
+	return gcm.Seal(out, nonce, plaintext, ad), nil
+}
+
+// open decrypts an envelope or legacy blob, returning the plaintext and the
+// associated data it was sealed with
+func (k *Keyring) open(blob []byte) (plaintext, associated []byte, err error) {
+	if !bytes.HasPrefix(blob, envelopeMagic) {
+		plaintext, err = k.openLegacy(blob)
+		return plaintext, nil, err
+	}
+
+	plaintext, associated, err = k.openEnvelope(blob)
+	if err != nil {
+		// One legacy nonce in 2^32 starts with the magic
+		if legacy, legacyErr := k.openLegacy(blob); legacyErr == nil {
+			return legacy, nil, nil
+		}
+	}
+	return plaintext, associated, err
+}
+
+func (k *Keyring) openEnvelope(blob []byte) (plaintext, associated []byte, err error) {
+	r := bytes.NewReader(blob[len(envelopeMagic):])
+	version, _ := r.ReadByte()
+	if version != envelopeVersion {
+		return nil, nil, fmt.Errorf("unsupported envelope version %d", version)
+	}
+	algorithm, _ := r.ReadByte()
+	if algorithm != AlgorithmAES256GCM {
+		return nil, nil, fmt.Errorf("unsupported envelope algorithm %d", algorithm)
+	}
+	keyIDLen, err := r.ReadByte()
+	if err != nil {
+		return nil, nil, errors.New("envelope too short")
+	}
+	keyID := make([]byte, keyIDLen)
+	if _, err := io.ReadFull(r, keyID); err != nil {
+		return nil, nil, errors.New("envelope too short")
+	}
+	var associatedLen uint16
+	if err := binary.Read(r, binary.BigEndian, &associatedLen); err != nil {
+		return nil, nil, errors.New("envelope too short")
+	}
+	associated = make([]byte, associatedLen)
+	if _, err := io.ReadFull(r, associated); err != nil {
+		return nil, nil, errors.New("envelope too short")
+	}
+	header := blob[:len(blob)-r.Len()]
+
+	key, ok := k.keys[string(keyID)]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w %q", ErrUnknownKey, keyID)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rest := blob[len(header):]
+	if len(rest) < gcm.NonceSize() {
+		return nil, nil, errors.New("envelope too short")
+	}
+	plaintext, err = gcm.Open(nil, rest[:gcm.NonceSize()], rest[gcm.NonceSize():], header)
+	if err != nil {
+		return nil, nil, err
+	}
+	return plaintext, associated, nil
+}
+
+// openLegacy decrypts a nonce||ciphertext blob. It names no key, so each is
+// tried, the active key first.
+func (k *Keyring) openLegacy(blob []byte) ([]byte, error) {
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		if id != k.active {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	var lastErr error
+	for _, id := range append([]string{k.active}, ids...) {
+		gcm, err := newGCM(k.keys[id])
+		if err != nil {
+			return nil, err
+		}
+		if len(blob) < gcm.NonceSize() {
+			return nil, fmt.Errorf("ciphertext too short")
+		}
+		nonce, ciphertext := blob[:gcm.NonceSize()], blob[gcm.NonceSize():]
+		plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+		if err == nil {
+			return plaintext, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
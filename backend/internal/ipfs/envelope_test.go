@@ -0,0 +1,129 @@
+package ipfs
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+var (
+	testKey1 = bytes.Repeat([]byte{1}, 32)
+	testKey2 = bytes.Repeat([]byte{2}, 32)
+)
+
+func testKeyring(t *testing.T, active string) *Keyring {
+	t.Helper()
+	ring, err := NewKeyring(active, map[string][]byte{"k1": testKey1, "k2": testKey2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ring
+}
+
+func sealed(t *testing.T, ring *Keyring, plaintext, associated []byte) []byte {
+	t.Helper()
+	blob, err := ring.seal(plaintext, associated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return blob
+}
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	ring := testKeyring(t, "k1")
+	for _, associated := range [][]byte{nil, []byte("claim:0xabc")} {
+		blob := sealed(t, ring, []byte(`{"claim":1}`), associated)
+
+		plaintext, gotAssociated, err := ring.open(blob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(plaintext) != `{"claim":1}` {
+			t.Errorf("got plaintext %q", plaintext)
+		}
+		if !bytes.Equal(gotAssociated, associated) {
+			t.Errorf("got associated data %q, want %q", gotAssociated, associated)
+		}
+	}
+
+	// Rotating the active key keeps older envelopes readable
+	blob := sealed(t, ring, []byte("old"), nil)
+	if plaintext, _, err := testKeyring(t, "k2").open(blob); err != nil || string(plaintext) != "old" {
+		t.Errorf("after rotation: got %q, %v", plaintext, err)
+	}
+}
+
+func TestOpenRejectsTampering(t *testing.T) {
+	ring := testKeyring(t, "k1")
+	blob := sealed(t, ring, []byte("payload"), []byte("purpose"))
+
+	// Header offsets: magic, version, algorithm, key ID length, key ID "k1",
+	// associated length, associated data
+	keyID := len(envelopeMagic) + 3
+	associated := keyID + len("k1") + 2
+
+	tests := []struct {
+		name   string
+		tamper func(b []byte)
+	}{
+		{"key ID", func(b []byte) { b[keyID+1] = '2' }}, // names k2, which exists
+		{"associated data", func(b []byte) { b[associated] ^= 1 }},
+		{"ciphertext", func(b []byte) { b[len(b)-1] ^= 1 }},
+	}
+	for _, tt := range tests {
+		tampered := append([]byte(nil), blob...)
+		tt.tamper(tampered)
+		if _, _, err := ring.open(tampered); err == nil {
+			t.Errorf("tampered %s opened", tt.name)
+		}
+	}
+}
+
+func TestOpenUnknownKey(t *testing.T) {
+	other, err := NewKeyring("k3", map[string][]byte{"k3": bytes.Repeat([]byte{3}, 32)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob := sealed(t, other, []byte("payload"), nil)
+
+	if _, _, err := testKeyring(t, "k1").open(blob); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("got %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestOpenLegacy(t *testing.T) {
+	ring := testKeyring(t, "k1")
+
+	// Legacy blobs name no key; one sealed with an inactive key still opens
+	for _, key := range [][]byte{testKey1, testKey2} {
+		gcm, err := newGCM(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonce := make([]byte, gcm.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			t.Fatal(err)
+		}
+		blob := gcm.Seal(append([]byte(nil), nonce...), nonce, []byte("legacy"), nil)
+
+		plaintext, associated, err := ring.open(blob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(plaintext) != "legacy" || associated != nil {
+			t.Errorf("got %q, %q", plaintext, associated)
+		}
+	}
+}
+
+func TestOpenTruncated(t *testing.T) {
+	ring := testKeyring(t, "k1")
+	blob := sealed(t, ring, []byte("payload"), []byte("purpose"))
+
+	for n := 0; n < len(blob); n++ {
+		if _, _, err := ring.open(blob[:n]); err == nil {
+			t.Errorf("envelope truncated to %d of %d bytes opened", n, len(blob))
+		}
+	}
+}
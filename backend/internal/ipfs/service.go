@@ -3,36 +3,55 @@ package ipfs
 import (
 	"bytes"
 	"context"
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
+	"github.com/saintparish4/apx/internal/canonical"
 	"github.com/saintparish4/apx/internal/domain"
+	"github.com/saintparish4/apx/internal/merkle"
+)
+
+// ErrInvalidClaimData is returned when retrieved claim data decrypts but does
+// not decode as a claim, e.g. because an amount is not dollars and cents, or
+// does not hash to the data hash it was sealed for
+var ErrInvalidClaimData = errors.New("invalid claim data")
+
+// ErrDecryption is returned when retrieved content does not decrypt with the
+// service's key
+var ErrDecryption = errors.New("decryption failed")
+
+// Associated data bound into envelopes. Claim data is bound to its data
+// hash, the on-chain commitment; its CID and claim ID cannot be bound as they
+// only exist once the payload is stored and submitted.
+const (
+	claimDataContext = "claim-data:"
+	documentContext  = "document"
 )
 
 // Service handles IPFS Operations
 type Service struct {
-	apiURL        string
-	gatewayURL    string
-	httpClient    *http.Client
-	encryptionKey []byte // AES-256 key for encryption
+	apiURL     string
+	gatewayURL string
+	httpClient *http.Client
+	keys       *Keyring // AES-256 keys for encryption
 }
 
 // NewService creates a new IPFS Service
-func NewService(apiURL, gatewayURL string, encryptionKey []byte) *Service {
+func NewService(apiURL, gatewayURL string, keys *Keyring) *Service {
 	return &Service{
 		apiURL:     apiURL,
 		gatewayURL: gatewayURL,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		encryptionKey: encryptionKey,
+		keys: keys,
 	}
 }
 
@@ -42,16 +61,22 @@ type AddResult struct {
 	Size string `json:"Size"`
 }
 
-// StoreClaimData encrypts and stores claim data in IPFS
+// StoreClaimData encrypts and stores claim data in IPFS. It records the
+// encryption key in data.EncryptionKeyID and binds the payload to the data
+// hash, so it must not be modified afterwards.
 func (s *Service) StoreClaimData(ctx context.Context, data *domain.ClaimData) (string, error) {
-	// Serialize to JSON
-	jsonData, err := json.Marshal(data)
+	data.EncryptionKeyID = s.keys.ActiveKeyID()
+
+	// Serialize to canonical JSON, the encoding the data hash is taken over
+	tree, err := merkle.Build(data)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal claim data: %w", err)
 	}
+	jsonData := tree.Canonical()
+	root := tree.Root()
 
 	// Encrypt the data
-	encryptedData, err := s.encrypt(jsonData)
+	encryptedData, err := s.keys.seal(jsonData, []byte(claimDataContext+hex.EncodeToString(root[:])))
 	if err != nil {
 		return "", fmt.Errorf("failed to encrypt claim data: %w", err)
 	}
@@ -80,15 +105,36 @@ func (s *Service) RetrieveClaimData(ctx context.Context, cid string) (*domain.Cl
 	}
 
 	// Decrypt
-	jsonData, err := s.decrypt(encryptedData)
+	jsonData, associated, err := s.keys.open(encryptedData)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt claim data: %w", err)
+		return nil, fmt.Errorf("failed to decrypt claim data: %w: %v", ErrDecryption, err)
 	}
 
-	// Deserialize
+	// Legacy payloads carry no associated data; enveloped ones must be claim
+	// data sealed for the data hash they hash to
+	bound, ok := strings.CutPrefix(string(associated), claimDataContext)
+	if associated != nil && !ok {
+		return nil, fmt.Errorf("%w: payload is sealed as %q, not claim data", ErrInvalidClaimData, associated)
+	}
+
+	// Deserialize. encoding/json keeps the last of repeated members, so
+	// payloads that are not I-JSON are refused first.
+	if _, err := canonical.Transform(jsonData); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidClaimData, err)
+	}
 	var data domain.ClaimData
 	if err := json.Unmarshal(jsonData, &data); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal claim data: %w", err)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidClaimData, err)
+	}
+
+	if associated != nil {
+		tree, err := merkle.Build(&data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidClaimData, err)
+		}
+		if root := tree.Root(); bound != hex.EncodeToString(root[:]) {
+			return nil, fmt.Errorf("%w: payload is sealed for data hash 0x%s but hashes to 0x%x", ErrInvalidClaimData, bound, root)
+		}
 	}
 
 	return &data, nil
@@ -100,7 +146,7 @@ func (s *Service) StoreRaw(ctx context.Context, data []byte, encrypt bool) (stri
 	var err error
 
 	if encrypt {
-		toStore, err = s.encrypt(data)
+		toStore, err = s.keys.seal(data, []byte(documentContext))
 		if err != nil {
 			return "", fmt.Errorf("failed to encrypt: %w", err)
 		}
@@ -119,7 +165,14 @@ func (s *Service) RetrieveRaw(ctx context.Context, cid string, encrypted bool) (
 	}
 
 	if encrypted {
-		return s.decrypt(data)
+		plaintext, associated, err := s.keys.open(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDecryption, err)
+		}
+		if associated != nil && string(associated) != documentContext {
+			return nil, fmt.Errorf("%w: content is sealed as %q, not a document", ErrDecryption, associated)
+		}
+		return plaintext, nil
 	}
 	return data, nil
 }
@@ -231,54 +284,6 @@ func (s *Service) cat(ctx context.Context, cid string) ([]byte, error) {
 	return io.ReadAll(resp.Body)
 }
 
-// encrypt encrypts data using AES-256-GCM
-func (s *Service) encrypt(plaintext []byte) ([]byte, error) {
-	block, err := aes.NewCipher(s.encryptionKey)
-	if err != nil {
-		return nil, err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return nil, err
-	}
-
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return nil, err
-	}
-
-	// Prepend nonce to ciphertext
-	ciphertext := gcm.Seal(nonce, nonce, plaintext, nil)
-	return ciphertext, nil
-}
-
-// decrypt decrypts data using AES-256-GCM
-func (s *Service) decrypt(ciphertext []byte) ([]byte, error) {
-	block, err := aes.NewCipher(s.encryptionKey)
-	if err != nil {
-		return nil, err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return nil, err
-	}
-
-	nonceSize := gcm.NonceSize()
-	if len(ciphertext) < nonceSize {
-		return nil, fmt.Errorf("ciphertext too short")
-	}
-
-	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	return plaintext, nil
-}
-
 // HealthCheck checks if IPFS node is available
 func (s *Service) HealthCheck(ctx context.Context) error {
 	url := fmt.Sprintf("%s/api/v0/id", s.apiURL)
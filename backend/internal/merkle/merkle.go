@@ -0,0 +1,302 @@
+// Package merkle commits to claim data as a Merkle tree of its canonical
+// fields, so single fields can be disclosed and proven against the on-chain
+// data hash without revealing the rest of the claim.
+//
+// Each scalar in the claim's canonical (RFC 8785) JSON is a leaf, addressed
+// by a path such as service_date or service_lines[0].procedure_code; empty
+// arrays and objects are leaves too. Leaves are taken depth-first with
+// object members in canonical order and array elements in order. The
+// commitment salt is not a leaf: it keys a per-leaf salt, so a disclosed
+// leaf reveals nothing that helps guess the others.
+//
+//	leaf salt = keccak256(salt || path)
+//	leaf      = keccak256(0x00 || leaf salt || JCS([path, value]))
+//	node      = keccak256(0x01 || left || right)
+//	root      = keccak256(0x02 || leaf count as 8 bytes big-endian || top node)
+//
+// A node without a sibling is promoted to the next level unchanged. The
+// root commits to the leaf count, which fixes where nodes are promoted, so a
+// proof's count cannot be altered either.
+package merkle
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/saintparish4/apx/internal/canonical"
+	"github.com/saintparish4/apx/internal/domain"
+)
+
+// ErrUnknownField is returned when a proof is requested for a path that
+// matches no leaf
+var ErrUnknownField = errors.New("unknown field")
+
+// ErrInvalidProof is returned when a proof does not lead to the root
+var ErrInvalidProof = errors.New("invalid proof")
+
+// saltField is the member of ClaimData holding the commitment salt
+const saltField = "salt"
+
+// Leaf is one committed field of claim data
+type Leaf struct {
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"` // canonical JSON
+	Salt  string          `json:"salt"`  // per-leaf salt, 0x-prefixed hex
+}
+
+// Hash returns the leaf's hash
+func (l *Leaf) Hash() ([32]byte, error) {
+	salt, err := decodeHash(l.Salt)
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("leaf salt: %w", err)
+	}
+	encoded, err := canonical.Marshal([]interface{}{l.Path, l.Value})
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return crypto.Keccak256Hash([]byte{0x00}, salt[:], encoded), nil
+}
+
+// Tree is the Merkle tree of one claim's fields
+type Tree struct {
+	canonical []byte
+	leaves    []Leaf
+	levels    [][][32]byte // levels[0] holds the leaf hashes, the last level the root
+}
+
+// Build commits to claim data. The salt, if set, must be valid.
+func Build(data *domain.ClaimData) (*Tree, error) {
+	var salt []byte
+	if data.Salt != "" {
+		if err := domain.CheckSalt(data.Salt); err != nil {
+			return nil, err
+		}
+		salt, _ = hex.DecodeString(strings.TrimPrefix(data.Salt, "0x"))
+	}
+
+	encoded, err := canonical.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to canonicalize claim data: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var fields map[string]interface{}
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, err
+	}
+	delete(fields, saltField)
+
+	t := &Tree{canonical: encoded}
+	if err := t.flatten("", fields, salt); err != nil {
+		return nil, err
+	}
+
+	hashes := make([][32]byte, len(t.leaves))
+	for i := range t.leaves {
+		if hashes[i], err = t.leaves[i].Hash(); err != nil {
+			return nil, err
+		}
+	}
+	t.levels = [][][32]byte{hashes}
+	for level := hashes; len(level) > 1; {
+		next := make([][32]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+			} else {
+				next = append(next, node(level[i], level[i+1]))
+			}
+		}
+		t.levels = append(t.levels, next)
+		level = next
+	}
+
+	return t, nil
+}
+
+// flatten appends a leaf per scalar, empty array and empty object under path
+func (t *Tree) flatten(path string, value interface{}, salt []byte) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			break
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		// Claim field names are ASCII, so byte order is canonical order
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			if err := t.flatten(child, v[key], salt); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		if len(v) == 0 {
+			break
+		}
+		for i, elem := range v {
+			if err := t.flatten(path+"["+strconv.Itoa(i)+"]", elem, salt); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	encoded, err := canonical.Marshal(value)
+	if err != nil {
+		return err
+	}
+	leafSalt := crypto.Keccak256(salt, []byte(path))
+	t.leaves = append(t.leaves, Leaf{
+		Path:  path,
+		Value: encoded,
+		Salt:  "0x" + hex.EncodeToString(leafSalt),
+	})
+	return nil
+}
+
+// Root returns the Merkle root, the claim's data hash
+func (t *Tree) Root() [32]byte {
+	top := t.levels[len(t.levels)-1]
+	if len(top) == 0 {
+		return [32]byte{}
+	}
+	return root(top[0], len(t.leaves))
+}
+
+// Canonical returns the canonical JSON of the claim data the tree was built
+// from, salt included
+func (t *Tree) Canonical() []byte {
+	return t.canonical
+}
+
+// Leaves returns the committed fields
+func (t *Tree) Leaves() []Leaf {
+	return t.leaves
+}
+
+// Proof shows that a field is committed to by a root
+type Proof struct {
+	Leaf
+	Index    int      `json:"index"`    // position among the leaves
+	Count    int      `json:"count"`    // number of leaves
+	Siblings []string `json:"siblings"` // sibling hashes from the leaf up
+}
+
+// Prove returns a proof for every leaf matching one of the paths. A path
+// matches its own leaf and every leaf below it, so service_lines[0]
+// discloses the whole first service line.
+func (t *Tree) Prove(paths ...string) ([]*Proof, error) {
+	var proofs []*Proof
+	for _, path := range paths {
+		found := false
+		for i, leaf := range t.leaves {
+			if path == "" || !within(leaf.Path, path) {
+				continue
+			}
+			found = true
+			proofs = append(proofs, t.prove(i))
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownField, path)
+		}
+	}
+	return proofs, nil
+}
+
+func (t *Tree) prove(index int) *Proof {
+	proof := &Proof{Leaf: t.leaves[index], Index: index, Count: len(t.leaves), Siblings: []string{}}
+	for _, level := range t.levels[:len(t.levels)-1] {
+		sibling := index ^ 1
+		if sibling < len(level) {
+			proof.Siblings = append(proof.Siblings, "0x"+hex.EncodeToString(level[sibling][:]))
+		}
+		index /= 2
+	}
+	return proof
+}
+
+// Verify checks that the proof leads from its leaf to the root want
+func (p *Proof) Verify(want [32]byte) error {
+	if p.Count < 1 || p.Index < 0 || p.Index >= p.Count {
+		return fmt.Errorf("%w: index %d out of %d leaves", ErrInvalidProof, p.Index, p.Count)
+	}
+
+	hash, err := p.Leaf.Hash()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProof, err)
+	}
+
+	siblings := p.Siblings
+	for index, count := p.Index, p.Count; count > 1; index, count = index/2, (count+1)/2 {
+		if index%2 == 0 && index+1 == count {
+			continue // promoted without a sibling
+		}
+		if len(siblings) == 0 {
+			return fmt.Errorf("%w: too few siblings", ErrInvalidProof)
+		}
+		sibling, err := decodeHash(siblings[0])
+		if err != nil {
+			return fmt.Errorf("%w: sibling: %v", ErrInvalidProof, err)
+		}
+		siblings = siblings[1:]
+		if index%2 == 0 {
+			hash = node(hash, sibling)
+		} else {
+			hash = node(sibling, hash)
+		}
+	}
+	if len(siblings) > 0 {
+		return fmt.Errorf("%w: too many siblings", ErrInvalidProof)
+	}
+
+	if proven := root(hash, p.Count); proven != want {
+		return fmt.Errorf("%w: root 0x%x does not match 0x%x", ErrInvalidProof, proven, want)
+	}
+	return nil
+}
+
+// within reports whether a leaf path is path or lies below it
+func within(leaf, path string) bool {
+	if !strings.HasPrefix(leaf, path) {
+		return false
+	}
+	rest := leaf[len(path):]
+	return rest == "" || rest[0] == '.' || rest[0] == '['
+}
+
+func node(left, right [32]byte) [32]byte {
+	return crypto.Keccak256Hash([]byte{0x01}, left[:], right[:])
+}
+
+func root(top [32]byte, count int) [32]byte {
+	var size [8]byte
+	binary.BigEndian.PutUint64(size[:], uint64(count))
+	return crypto.Keccak256Hash([]byte{0x02}, size[:], top[:])
+}
+
+func decodeHash(value string) ([32]byte, error) {
+	var out [32]byte
+	raw, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+	if err != nil || len(raw) != len(out) {
+		return out, fmt.Errorf("%q is not a 32-byte hex hash", value)
+	}
+	copy(out[:], raw)
+	return out, nil
+}
@@ -0,0 +1,231 @@
+package merkle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/saintparish4/apx/internal/domain"
+)
+
+const testSalt = "0x000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+// claimData returns claim data whose tree has one leaf per procedure code
+// plus a fixed number of others, so the leaf count follows n
+func claimData(n int) *domain.ClaimData {
+	billed := domain.Money(15050)
+	data := &domain.ClaimData{
+		PatientID:           "0xpatient",
+		ProviderNPI:         "1234567893",
+		ServiceDate:         "2026-10-01",
+		DiagnosisCodes:      []string{"E11.9"},
+		PlaceOfService:      "11",
+		BilledAmount:        &billed,
+		SubmissionTimestamp: 1760000000,
+		ClaimType:           domain.ClaimTypeProfessional,
+		Salt:                testSalt,
+	}
+	for i := 0; i < n; i++ {
+		data.ProcedureCodes = append(data.ProcedureCodes, fmt.Sprintf("%05d", 99201+i))
+	}
+	return data
+}
+
+func build(t *testing.T, data *domain.ClaimData) *Tree {
+	t.Helper()
+	tree, err := Build(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+// Every leaf of trees of 12 sizes proves, covering leaves promoted without a
+// sibling at one or more levels
+func TestProveVerifyRoundTrip(t *testing.T) {
+	sizes := map[bool]bool{}
+	for n := 1; n <= 12; n++ {
+		tree := build(t, claimData(n))
+		root := tree.Root()
+		sizes[len(tree.Leaves())%2 == 1] = true
+
+		for _, leaf := range tree.Leaves() {
+			proofs, err := tree.Prove(leaf.Path)
+			if err != nil {
+				t.Fatalf("%d leaves, %s: %v", len(tree.Leaves()), leaf.Path, err)
+			}
+			if len(proofs) != 1 {
+				t.Fatalf("%d leaves, %s: got %d proofs, want 1", len(tree.Leaves()), leaf.Path, len(proofs))
+			}
+
+			// Proofs travel as JSON
+			raw, err := json.Marshal(proofs[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			var proof Proof
+			if err := json.Unmarshal(raw, &proof); err != nil {
+				t.Fatal(err)
+			}
+			if err := proof.Verify(root); err != nil {
+				t.Errorf("%d leaves, %s (index %d): %v", len(tree.Leaves()), leaf.Path, proof.Index, err)
+			}
+		}
+	}
+	if !sizes[true] || !sizes[false] {
+		t.Fatal("expected trees with both odd and even leaf counts")
+	}
+}
+
+func TestProveSubtree(t *testing.T) {
+	data := claimData(11)
+	data.ServiceLines = []domain.ServiceLine{
+		{ProcedureCode: "99213", Modifiers: []string{"25"}, Units: 1, Charge: 10050, DiagnosisPointers: []int{1}},
+		{ProcedureCode: "36415", Units: 1, Charge: 5000},
+	}
+	tree := build(t, data)
+
+	proofs, err := tree.Prove("service_lines[0]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"service_lines[0].charge",
+		"service_lines[0].diagnosis_pointers[0]",
+		"service_lines[0].modifiers[0]",
+		"service_lines[0].procedure_code",
+		"service_lines[0].units",
+	}
+	var got []string
+	for _, proof := range proofs {
+		got = append(got, proof.Path)
+		if err := proof.Verify(tree.Root()); err != nil {
+			t.Errorf("%s: %v", proof.Path, err)
+		}
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("got paths %v, want %v", got, want)
+	}
+
+	// An index is not a prefix of longer indexes
+	proofs, err = tree.Prove("procedure_codes[1]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proofs) != 1 || proofs[0].Path != "procedure_codes[1]" {
+		t.Errorf("procedure_codes[1] matched %d leaves", len(proofs))
+	}
+}
+
+func TestProveUnknownField(t *testing.T) {
+	tree := build(t, claimData(2))
+	for _, path := range []string{"", "nope", "procedure_codes[2]", "service_date.year", "service"} {
+		if _, err := tree.Prove(path); !errors.Is(err, ErrUnknownField) {
+			t.Errorf("%q: got %v, want ErrUnknownField", path, err)
+		}
+	}
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	tree := build(t, claimData(8)) // an odd leaf count
+	root := tree.Root()
+	count := len(tree.Leaves())
+	if count%2 == 0 {
+		t.Fatalf("expected an odd leaf count, got %d", count)
+	}
+
+	tests := []struct {
+		name   string
+		tamper func(p *Proof)
+	}{
+		{"sibling", func(p *Proof) { p.Siblings[0] = flip(p.Siblings[0]) }},
+		{"sibling order", func(p *Proof) {
+			p.Siblings[0], p.Siblings[len(p.Siblings)-1] = p.Siblings[len(p.Siblings)-1], p.Siblings[0]
+		}},
+		{"missing sibling", func(p *Proof) { p.Siblings = p.Siblings[:len(p.Siblings)-1] }},
+		{"extra sibling", func(p *Proof) { p.Siblings = append(p.Siblings, p.Siblings[0]) }},
+		{"index", func(p *Proof) { p.Index ^= 1 }},
+		{"negative index", func(p *Proof) { p.Index = -1 }},
+		{"index past count", func(p *Proof) { p.Index = p.Count }},
+		{"count up", func(p *Proof) { p.Count++ }},
+		{"count down", func(p *Proof) { p.Count-- }},
+		{"zero count", func(p *Proof) { p.Count = 0 }},
+		{"value", func(p *Proof) { p.Value = json.RawMessage(`"99999"`) }},
+		{"path", func(p *Proof) { p.Path = "procedure_codes[9]" }},
+		{"leaf salt", func(p *Proof) { p.Salt = flip(p.Salt) }},
+		{"malformed sibling", func(p *Proof) { p.Siblings[0] = "0x1234" }},
+	}
+
+	for i, leaf := range tree.Leaves() {
+		// The last leaf is promoted; the others are not, and the first
+		// has the most siblings on its path
+		if i != 0 && i != count-1 && i != count/2 {
+			continue
+		}
+		for _, tt := range tests {
+			proofs, err := tree.Prove(leaf.Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			proof := proofs[0]
+			if len(proof.Siblings) == 0 {
+				t.Fatalf("%s: proof has no siblings", leaf.Path)
+			}
+			if tt.name == "index" && proof.Index^1 >= count || tt.name == "sibling order" && len(proof.Siblings) < 2 {
+				continue
+			}
+
+			tt.tamper(proof)
+			if err := proof.Verify(root); !errors.Is(err, ErrInvalidProof) {
+				t.Errorf("%s, tampered %s: got %v, want ErrInvalidProof", leaf.Path, tt.name, err)
+			}
+		}
+	}
+}
+
+// Every count but the true one is rejected, including counts that leave the
+// leaf's path through the tree unchanged
+func TestVerifyRejectsAnyOtherCount(t *testing.T) {
+	tree := build(t, claimData(8))
+	proofs, err := tree.Prove(tree.Leaves()[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof := proofs[0]
+	for count := 1; count <= 4*proof.Count; count++ {
+		if count == proof.Count {
+			continue
+		}
+		tampered := *proof
+		tampered.Count = count
+		if err := tampered.Verify(tree.Root()); err == nil {
+			t.Errorf("count %d (true count %d) verified", count, proof.Count)
+		}
+	}
+}
+
+func TestRootDependsOnSalt(t *testing.T) {
+	salted := build(t, claimData(3))
+
+	other := claimData(3)
+	other.Salt = "0x" + strings.Repeat("ff", domain.SaltSize)
+	if build(t, other).Root() == salted.Root() {
+		t.Error("roots of differently salted data match")
+	}
+
+	other.Salt = "0x1234"
+	if _, err := Build(other); err == nil {
+		t.Error("expected an error for a short salt")
+	}
+}
+
+// flip changes the last hex digit of a 0x-prefixed hash
+func flip(value string) string {
+	last := value[len(value)-1]
+	if last == '0' {
+		return value[:len(value)-1] + "1"
+	}
+	return value[:len(value)-1] + "0"
+}
@@ -0,0 +1,237 @@
+// Package ncci loads National Correct Coding Initiative edit tables:
+// procedure-to-procedure (PTP) edits and Medically Unlikely Edits (MUEs).
+package ncci
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/saintparish4/apx/internal/refdata"
+)
+
+// ModifierIndicator says whether an NCCI-associated modifier can bypass a
+// PTP edit
+type ModifierIndicator int
+
+const (
+	ModifierNotAllowed    ModifierIndicator = 0
+	ModifierAllowed       ModifierIndicator = 1
+	ModifierNotApplicable ModifierIndicator = 9 // edit deleted retroactively
+)
+
+// bypassModifiers are the NCCI-associated modifiers that can bypass a PTP
+// edit whose modifier indicator is 1
+var bypassModifiers = map[string]bool{
+	"24": true, "25": true, "27": true, "57": true, "58": true, "59": true,
+	"78": true, "79": true, "91": true,
+	"XE": true, "XP": true, "XS": true, "XU": true,
+	"E1": true, "E2": true, "E3": true, "E4": true,
+	"FA": true, "F1": true, "F2": true, "F3": true, "F4": true,
+	"F5": true, "F6": true, "F7": true, "F8": true, "F9": true,
+	"LT": true, "RT": true, "LC": true, "LD": true, "LM": true, "RC": true, "RI": true,
+	"TA": true, "T1": true, "T2": true, "T3": true, "T4": true,
+	"T5": true, "T6": true, "T7": true, "T8": true, "T9": true,
+}
+
+// IsBypassModifier reports whether a modifier is NCCI-associated
+func IsBypassModifier(modifier string) bool {
+	return bypassModifiers[strings.ToUpper(modifier)]
+}
+
+// PTPEdit is a code pair that should not be billed together for the same
+// patient and date of service
+type PTPEdit struct {
+	Column1           string            `json:"column1"`
+	Column2           string            `json:"column2"`
+	ModifierIndicator ModifierIndicator `json:"modifier_indicator"`
+	Rationale         string            `json:"rationale,omitempty"`
+	Version           string            `json:"version"`
+	Period            refdata.Period    `json:"period"`
+}
+
+// MUE adjudication indicators
+const (
+	MUEPerLine             = 1
+	MUEPerDayPolicy        = 2
+	MUEPerDayClinicalBasis = 3
+)
+
+// MUEEdit is the maximum units of service for a code
+type MUEEdit struct {
+	Code                  string         `json:"code"`
+	MaxUnits              int            `json:"max_units"`
+	AdjudicationIndicator int            `json:"adjudication_indicator"`
+	Rationale             string         `json:"rationale,omitempty"`
+	Version               string         `json:"version"`
+	Period                refdata.Period `json:"period"`
+}
+
+// PerLine reports whether the MUE applies to each claim line separately
+// rather than to all units on the date of service
+func (e *MUEEdit) PerLine() bool {
+	return e.AdjudicationIndicator == MUEPerLine
+}
+
+// Edits holds every loaded PTP and MUE table version
+type Edits struct {
+	ptp         map[string][]*PTPEdit
+	mue         map[string][]*MUEEdit
+	ptpVersions []string
+	mueVersions []string
+}
+
+// Load reads <dir>/ptp/<version>.csv (column1, column2, effective_date,
+// deletion_date, modifier_indicator) and <dir>/mue/<version>.csv (code,
+// max_units, adjudication_indicator)
+func Load(dir string) (*Edits, error) {
+	e := &Edits{
+		ptp: make(map[string][]*PTPEdit),
+		mue: make(map[string][]*MUEEdit),
+	}
+
+	ptpFiles, err := refdata.ListFiles(filepath.Join(dir, "ptp"), ".csv")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to list PTP edit tables: %w", err)
+	}
+	for _, file := range ptpFiles {
+		if err := e.loadPTP(file); err != nil {
+			return nil, err
+		}
+	}
+
+	mueFiles, err := refdata.ListFiles(filepath.Join(dir, "mue"), ".csv")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to list MUE tables: %w", err)
+	}
+	for _, file := range mueFiles {
+		if err := e.loadMUE(file); err != nil {
+			return nil, err
+		}
+	}
+
+	return e, nil
+}
+
+func (e *Edits) loadPTP(path string) error {
+	table, err := refdata.ReadCSV(path, "column1", "column2", "modifier_indicator")
+	if err != nil {
+		return err
+	}
+
+	for i, row := range table.Rows {
+		// CMS publishes "*" for edits with no deletion date
+		if row.Get("deletion_date") == "*" {
+			row["deletion_date"] = ""
+		}
+		period, err := refdata.ParsePeriod(row, "effective_date", "deletion_date")
+		if err != nil {
+			return fmt.Errorf("%s: row %d: %w", path, i+2, err)
+		}
+
+		indicator, err := strconv.Atoi(row.Get("modifier_indicator"))
+		if err != nil {
+			return fmt.Errorf("%s: row %d: invalid modifier_indicator %q", path, i+2, row.Get("modifier_indicator"))
+		}
+
+		edit := &PTPEdit{
+			Column1:           strings.ToUpper(row.Get("column1")),
+			Column2:           strings.ToUpper(row.Get("column2")),
+			ModifierIndicator: ModifierIndicator(indicator),
+			Rationale:         row.Get("rationale"),
+			Version:           table.Version,
+			Period:            period,
+		}
+
+		key := pairKey(edit.Column1, edit.Column2)
+		e.ptp[key] = append(e.ptp[key], edit)
+	}
+
+	e.ptpVersions = append(e.ptpVersions, table.Version)
+	return nil
+}
+
+func (e *Edits) loadMUE(path string) error {
+	table, err := refdata.ReadCSV(path, "code", "max_units")
+	if err != nil {
+		return err
+	}
+
+	for i, row := range table.Rows {
+		period, err := refdata.ParsePeriod(row, "effective_date", "termination_date")
+		if err != nil {
+			return fmt.Errorf("%s: row %d: %w", path, i+2, err)
+		}
+
+		maxUnits, err := strconv.Atoi(row.Get("max_units"))
+		if err != nil || maxUnits < 0 {
+			return fmt.Errorf("%s: row %d: invalid max_units %q", path, i+2, row.Get("max_units"))
+		}
+
+		indicator := MUEPerLine
+		if value := row.Get("adjudication_indicator"); value != "" {
+			// Files often spell the indicator out, e.g. "2 Date of Service Edit: Policy"
+			indicator, err = strconv.Atoi(strings.Fields(value)[0])
+			if err != nil {
+				return fmt.Errorf("%s: row %d: invalid adjudication_indicator %q", path, i+2, value)
+			}
+		}
+
+		edit := &MUEEdit{
+			Code:                  strings.ToUpper(row.Get("code")),
+			MaxUnits:              maxUnits,
+			AdjudicationIndicator: indicator,
+			Rationale:             row.Get("rationale"),
+			Version:               table.Version,
+			Period:                period,
+		}
+		e.mue[edit.Code] = append(e.mue[edit.Code], edit)
+	}
+
+	e.mueVersions = append(e.mueVersions, table.Version)
+	return nil
+}
+
+// Loaded reports whether any edits were loaded
+func (e *Edits) Loaded() bool {
+	return e != nil && (len(e.ptp) > 0 || len(e.mue) > 0)
+}
+
+// Versions returns the loaded PTP and MUE table versions
+func (e *Edits) Versions() (ptp, mue []string) {
+	return append([]string(nil), e.ptpVersions...), append([]string(nil), e.mueVersions...)
+}
+
+// PairEdit returns the PTP edit in force on a date for two codes, in either
+// column order
+func (e *Edits) PairEdit(a, b string, on time.Time) (*PTPEdit, bool) {
+	a, b = strings.ToUpper(a), strings.ToUpper(b)
+
+	for _, key := range []string{pairKey(a, b), pairKey(b, a)} {
+		for _, edit := range e.ptp[key] {
+			if edit.Period.Contains(on) {
+				return edit, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// MUE returns the medically unlikely edit in force on a date for a code
+func (e *Edits) MUE(code string, on time.Time) (*MUEEdit, bool) {
+	for _, edit := range e.mue[strings.ToUpper(code)] {
+		if edit.Period.Contains(on) {
+			return edit, true
+		}
+	}
+	return nil, false
+}
+
+func pairKey(column1, column2 string) string {
+	return column1 + "|" + column2
+}
@@ -0,0 +1,74 @@
+// Package npi validates National Provider Identifiers and reads records from
+// the CMS NPPES downloadable file.
+package npi
+
+import (
+	"time"
+)
+
+// healthcarePrefix is the ISO 7812 issuer prefix (80840) for US health
+// applications. Its Luhn contribution is the constant 24 that CMS documents
+// for the NPI check digit.
+const healthcarePrefix = 24
+
+// Valid reports whether s is a 10-digit NPI with a correct check digit
+func Valid(s string) bool {
+	if len(s) != 10 {
+		return false
+	}
+
+	sum := healthcarePrefix
+	for i := 0; i < 9; i++ {
+		c := s[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		digit := int(c - '0')
+		// Double every other digit, starting with the leftmost
+		if i%2 == 0 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+	}
+
+	check := s[9]
+	if check < '0' || check > '9' {
+		return false
+	}
+
+	return (10-sum%10)%10 == int(check-'0')
+}
+
+// Record is the subset of an NPPES entry the verifier relies on
+type Record struct {
+	NPI              string    `json:"npi"`
+	EntityType       int       `json:"entity_type"` // 1 individual, 2 organization
+	Name             string    `json:"name"`
+	State            string    `json:"state,omitempty"`
+	TaxonomyCode     string    `json:"taxonomy_code,omitempty"`
+	DeactivationDate time.Time `json:"deactivation_date,omitempty"`
+	ReactivationDate time.Time `json:"reactivation_date,omitempty"`
+}
+
+// DeactivatedOn reports whether the NPI was deactivated (and not since
+// reactivated) as of the given date. A zero date means today.
+func (r *Record) DeactivatedOn(on time.Time) bool {
+	if r.DeactivationDate.IsZero() {
+		return false
+	}
+	if on.IsZero() {
+		on = time.Now()
+	}
+	if on.Before(r.DeactivationDate) {
+		return false
+	}
+
+	reactivated := !r.ReactivationDate.IsZero() &&
+		r.ReactivationDate.After(r.DeactivationDate) &&
+		!on.Before(r.ReactivationDate)
+
+	return !reactivated
+}
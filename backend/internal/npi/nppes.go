@@ -0,0 +1,105 @@
+package npi
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Column names in the NPPES Data Dissemination file (npidata_pfile_*.csv)
+const (
+	colNPI              = "NPI"
+	colEntityType       = "Entity Type Code"
+	colOrgName          = "Provider Organization Name (Legal Business Name)"
+	colLastName         = "Provider Last Name (Legal Name)"
+	colFirstName        = "Provider First Name"
+	colState            = "Provider Business Practice Location Address State Name"
+	colTaxonomy         = "Healthcare Provider Taxonomy Code_1"
+	colDeactivationDate = "NPI Deactivation Date"
+	colReactivationDate = "NPI Reactivation Date"
+)
+
+// nppesDateLayout is the MM/DD/YYYY format NPPES uses for dates
+const nppesDateLayout = "01/02/2006"
+
+// ReadNPPES streams records from an NPPES CSV (the full monthly file or a
+// subset with the same header) and calls fn for each one. The bulk file is
+// several gigabytes, so records are never held in memory together.
+func ReadNPPES(r io.Reader, fn func(*Record) error) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read NPPES header: %w", err)
+	}
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.TrimSpace(name)] = i
+	}
+	if _, ok := index[colNPI]; !ok {
+		return fmt.Errorf("NPPES file has no %q column", colNPI)
+	}
+
+	get := func(record []string, col string) string {
+		i, ok := index[col]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		line++
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+
+		rec := &Record{
+			NPI:          get(record, colNPI),
+			State:        get(record, colState),
+			TaxonomyCode: get(record, colTaxonomy),
+		}
+
+		if entityType := get(record, colEntityType); entityType != "" {
+			rec.EntityType, err = strconv.Atoi(entityType)
+			if err != nil {
+				return fmt.Errorf("line %d: invalid entity type %q", line, entityType)
+			}
+		}
+
+		if rec.EntityType == 2 {
+			rec.Name = get(record, colOrgName)
+		} else {
+			rec.Name = strings.TrimSpace(get(record, colFirstName) + " " + get(record, colLastName))
+		}
+
+		if rec.DeactivationDate, err = parseNPPESDate(get(record, colDeactivationDate)); err != nil {
+			return fmt.Errorf("line %d: invalid deactivation date: %w", line, err)
+		}
+		if rec.ReactivationDate, err = parseNPPESDate(get(record, colReactivationDate)); err != nil {
+			return fmt.Errorf("line %d: invalid reactivation date: %w", line, err)
+		}
+
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+}
+
+func parseNPPESDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(nppesDateLayout, value)
+}
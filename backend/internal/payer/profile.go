@@ -0,0 +1,160 @@
+// Package payer loads payer profiles: the timely filing limits, accepted
+// claim types, covered procedures and score weights each payer applies, so
+// one verifier network can adjudicate claims for several payers.
+package payer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/saintparish4/apx/internal/refdata"
+)
+
+// Profile is the policy of a single payer
+type Profile struct {
+	ID   string `json:"id"` // payer ID carried on claims
+	Name string `json:"name"`
+
+	// Claims must be submitted within this many days of the service date.
+	// Corrected claims get CorrectedClaimDays instead when it is set.
+	TimelyFilingDays   int `json:"timely_filing_days"`
+	CorrectedClaimDays int `json:"corrected_claim_days,omitempty"`
+
+	ClaimTypes        []string `json:"claim_types,omitempty"`        // accepted claim types; empty accepts all
+	CoveredProcedures []string `json:"covered_procedures,omitempty"` // codes, ranges (27000-27899) or wildcards (992*); empty covers all
+
+	ScoreWeights ScoreWeights `json:"score_weights"`
+
+	Hash string `json:"-"` // sha256 of the profile file, identifies its contents
+
+	procedures refdata.Patterns
+}
+
+// ScoreWeights override the active ruleset's penalties for the payer's
+// claims. Nil weights keep the ruleset's.
+type ScoreWeights struct {
+	ErrorPenalty   *float64 `json:"error_penalty,omitempty"`
+	WarningPenalty *float64 `json:"warning_penalty,omitempty"`
+}
+
+// FilingLimitDays returns the timely filing limit for an original or a
+// corrected claim
+func (p *Profile) FilingLimitDays(corrected bool) int {
+	if corrected && p.CorrectedClaimDays > 0 {
+		return p.CorrectedClaimDays
+	}
+	return p.TimelyFilingDays
+}
+
+// AcceptsClaimType reports whether the payer takes claims of a type
+func (p *Profile) AcceptsClaimType(claimType string) bool {
+	if len(p.ClaimTypes) == 0 {
+		return true
+	}
+	for _, accepted := range p.ClaimTypes {
+		if accepted == claimType {
+			return true
+		}
+	}
+	return false
+}
+
+// CoversProcedure reports whether the payer covers a procedure code
+func (p *Profile) CoversProcedure(code string) bool {
+	return len(p.procedures) == 0 || p.procedures.Match(code)
+}
+
+// Profiles is the set of loaded payer profiles
+type Profiles struct {
+	profiles map[string]*Profile
+}
+
+// Load reads every .json file in dir, each holding one payer profile
+func Load(dir string) (*Profiles, error) {
+	ps := &Profiles{profiles: map[string]*Profile{}}
+
+	files, err := refdata.ListFiles(dir, ".json")
+	if errors.Is(err, os.ErrNotExist) {
+		return ps, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payer profiles: %w", err)
+	}
+
+	for _, file := range files {
+		profile, err := load(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if _, exists := ps.profiles[profile.ID]; exists {
+			return nil, fmt.Errorf("%s: duplicate payer ID %s", file, profile.ID)
+		}
+		ps.profiles[profile.ID] = profile
+	}
+
+	return ps, nil
+}
+
+func load(file string) (*Profile, error) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &Profile{}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(profile); err != nil {
+		return nil, err
+	}
+
+	if profile.ID == "" {
+		return nil, errors.New("payer profile needs an id")
+	}
+	if profile.TimelyFilingDays <= 0 || profile.CorrectedClaimDays < 0 {
+		return nil, fmt.Errorf("payer %s: timely filing limits must be positive", profile.ID)
+	}
+	if profile.procedures, err = refdata.CompilePatterns(profile.CoveredProcedures); err != nil {
+		return nil, fmt.Errorf("payer %s: covered_procedures: %w", profile.ID, err)
+	}
+
+	sum := sha256.Sum256(raw)
+	profile.Hash = "sha256:" + hex.EncodeToString(sum[:])
+
+	return profile, nil
+}
+
+// Loaded reports whether any payer profiles were loaded
+func (ps *Profiles) Loaded() bool {
+	return ps != nil && len(ps.profiles) > 0
+}
+
+// Lookup returns the profile of a payer
+func (ps *Profiles) Lookup(id string) (*Profile, bool) {
+	if ps == nil {
+		return nil, false
+	}
+	profile, ok := ps.profiles[id]
+	return profile, ok
+}
+
+// Profiles returns the loaded profiles ordered by payer ID
+func (ps *Profiles) Profiles() []*Profile {
+	if ps == nil {
+		return nil
+	}
+	profiles := make([]*Profile, 0, len(ps.profiles))
+	for _, profile := range ps.profiles {
+		profiles = append(profiles, profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].ID < profiles[j].ID
+	})
+	return profiles
+}
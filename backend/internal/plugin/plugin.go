@@ -0,0 +1,297 @@
+// Package plugin runs third-party validation rules compiled to WebAssembly.
+//
+// A plugin is a manifest (<name>.json) next to a module (<name>.wasm). The
+// module runs in a wazero sandbox with no filesystem, network, environment or
+// real clock; WASI is available so toolchains that need it (Go, TinyGo, Rust
+// wasm32-wasi) work unchanged. It must export:
+//
+//	memory
+//	apx_alloc(size i32) -> i32          buffer for the input, in linear memory
+//	apx_check(ptr i32, len i32) -> i64  (result ptr << 32) | result len
+//
+// apx_check receives domain.ClaimData as JSON and returns a JSON Result. Each
+// check runs in a fresh instance, so modules keep no state between claims.
+package plugin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/saintparish4/apx/internal/refdata"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+const (
+	allocExport = "apx_alloc"
+	checkExport = "apx_check"
+
+	// maxResultSize bounds the JSON a module may return
+	maxResultSize = 64 * 1024
+
+	wasmPageSize = 64 * 1024
+)
+
+var nameRegex = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// Limits bound the resources a single check may use
+type Limits struct {
+	Timeout       time.Duration // wall-clock time per check, including instantiation
+	MemoryBytes   uint32        // linear memory per instance
+	MaxConcurrent int           // checks running at once across all plugins
+}
+
+// Manifest describes a plugin rule
+type Manifest struct {
+	Name        string `json:"name"` // lower-case identifier; the rule is plugin.<name>
+	Description string `json:"description"`
+	Severity    string `json:"severity"`         // error or warning
+	Module      string `json:"module,omitempty"` // defaults to <manifest name>.wasm
+}
+
+// Result is what a module returns for a claim
+type Result struct {
+	Pass    bool   `json:"pass"`
+	Message string `json:"message"`
+	Path    string `json:"path,omitempty"` // JSON path of the offending field
+}
+
+// Rule is a loaded plugin
+type Rule struct {
+	Manifest
+	Hash string // sha256 of the module, identifies the rule's behaviour
+
+	set      *Set
+	compiled wazero.CompiledModule
+}
+
+// Set is the collection of loaded plugins sharing one sandboxed runtime
+type Set struct {
+	runtime wazero.Runtime
+	limits  Limits
+	slots   chan struct{}
+	rules   []*Rule
+}
+
+// Load compiles every plugin manifest in dir
+func Load(ctx context.Context, dir string, limits Limits) (*Set, error) {
+	set := &Set{limits: limits}
+
+	files, err := refdata.ListFiles(dir, ".json")
+	if errors.Is(err, os.ErrNotExist) {
+		return set, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list plugins: %w", err)
+	}
+	if len(files) == 0 {
+		return set, nil
+	}
+
+	if limits.Timeout <= 0 || limits.MemoryBytes < wasmPageSize || limits.MaxConcurrent < 1 {
+		return nil, fmt.Errorf("invalid plugin limits: %+v", limits)
+	}
+	set.slots = make(chan struct{}, limits.MaxConcurrent)
+	set.runtime = wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(limits.MemoryBytes/wasmPageSize).
+		WithCloseOnContextDone(true))
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, set.runtime); err != nil {
+		set.Close(ctx)
+		return nil, fmt.Errorf("failed to instantiate WASI: %w", err)
+	}
+
+	names := map[string]string{}
+	for _, file := range files {
+		rule, err := set.load(ctx, file)
+		if err != nil {
+			set.Close(ctx)
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if other, exists := names[rule.Name]; exists {
+			set.Close(ctx)
+			return nil, fmt.Errorf("%s: duplicate plugin name %s (also in %s)", file, rule.Name, other)
+		}
+		names[rule.Name] = file
+		set.rules = append(set.rules, rule)
+	}
+
+	return set, nil
+}
+
+func (s *Set) load(ctx context.Context, file string) (*Rule, error) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	rule := &Rule{set: s}
+	if err := json.Unmarshal(raw, &rule.Manifest); err != nil {
+		return nil, err
+	}
+	if !nameRegex.MatchString(rule.Name) {
+		return nil, fmt.Errorf("invalid plugin name %q", rule.Name)
+	}
+	if rule.Severity != "error" && rule.Severity != "warning" {
+		return nil, fmt.Errorf("severity must be error or warning, got %q", rule.Severity)
+	}
+	if rule.Module == "" {
+		rule.Module = strings.TrimSuffix(filepath.Base(file), ".json") + ".wasm"
+	}
+
+	binary, err := os.ReadFile(filepath.Join(filepath.Dir(file), rule.Module))
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(binary)
+	rule.Hash = "sha256:" + hex.EncodeToString(sum[:])
+
+	rule.compiled, err = s.runtime.CompileModule(ctx, binary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile %s: %w", rule.Module, err)
+	}
+	if err := checkInterface(rule.compiled); err != nil {
+		return nil, fmt.Errorf("%s: %w", rule.Module, err)
+	}
+
+	return rule, nil
+}
+
+// checkInterface verifies a module imports nothing beyond WASI and exports
+// the plugin ABI
+func checkInterface(compiled wazero.CompiledModule) error {
+	for _, imported := range compiled.ImportedFunctions() {
+		module, name, _ := imported.Import()
+		if module != wasi_snapshot_preview1.ModuleName {
+			return fmt.Errorf("imports %s.%s; only %s is available", module, name, wasi_snapshot_preview1.ModuleName)
+		}
+	}
+
+	if _, ok := compiled.ExportedMemories()["memory"]; !ok {
+		return errors.New("does not export memory")
+	}
+
+	exports := compiled.ExportedFunctions()
+	for name, signature := range map[string]struct{ params, results []api.ValueType }{
+		allocExport: {[]api.ValueType{api.ValueTypeI32}, []api.ValueType{api.ValueTypeI32}},
+		checkExport: {[]api.ValueType{api.ValueTypeI32, api.ValueTypeI32}, []api.ValueType{api.ValueTypeI64}},
+	} {
+		fn, ok := exports[name]
+		if !ok {
+			return fmt.Errorf("does not export %s", name)
+		}
+		if !sameTypes(fn.ParamTypes(), signature.params) || !sameTypes(fn.ResultTypes(), signature.results) {
+			return fmt.Errorf("%s has the wrong signature", name)
+		}
+	}
+
+	return nil
+}
+
+func sameTypes(a, b []api.ValueType) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Loaded reports whether any plugins were loaded
+func (s *Set) Loaded() bool {
+	return s != nil && len(s.rules) > 0
+}
+
+// Rules returns the loaded plugins in file name order
+func (s *Set) Rules() []*Rule {
+	if s == nil {
+		return nil
+	}
+	return append([]*Rule(nil), s.rules...)
+}
+
+// Close releases the runtime and every compiled module
+func (s *Set) Close(ctx context.Context) error {
+	if s == nil || s.runtime == nil {
+		return nil
+	}
+	return s.runtime.Close(ctx)
+}
+
+// Check runs the plugin against a claim encoded as JSON. An error means the
+// module misbehaved (trapped, ran out of time or memory, or returned an
+// invalid result), not that the claim failed.
+func (r *Rule) Check(ctx context.Context, claim []byte) (*Result, error) {
+	limits := r.set.limits
+
+	// Bound the CPU plugins can occupy; waiting for a slot counts against
+	// the time limit
+	ctx, cancel := context.WithTimeout(ctx, limits.Timeout)
+	defer cancel()
+	select {
+	case r.set.slots <- struct{}{}:
+		defer func() { <-r.set.slots }()
+	case <-ctx.Done():
+		return nil, fmt.Errorf("no capacity within %s", limits.Timeout)
+	}
+
+	// Anonymous instances can run concurrently; stdio is discarded and the
+	// clock and random source are wazero's deterministic defaults
+	module, err := r.set.runtime.InstantiateModule(ctx, r.compiled, wazero.NewModuleConfig().
+		WithName("").
+		WithStartFunctions("_initialize"))
+	if err != nil {
+		return nil, r.callError(ctx, "instantiate", err)
+	}
+	defer module.Close(context.Background())
+
+	allocated, err := module.ExportedFunction(allocExport).Call(ctx, uint64(len(claim)))
+	if err != nil {
+		return nil, r.callError(ctx, allocExport, err)
+	}
+	ptr := uint32(allocated[0])
+	if !module.Memory().Write(ptr, claim) {
+		return nil, fmt.Errorf("%s returned an out-of-range buffer", allocExport)
+	}
+
+	packed, err := module.ExportedFunction(checkExport).Call(ctx, uint64(ptr), uint64(len(claim)))
+	if err != nil {
+		return nil, r.callError(ctx, checkExport, err)
+	}
+	resultPtr, resultLen := uint32(packed[0]>>32), uint32(packed[0])
+	if resultLen > maxResultSize {
+		return nil, fmt.Errorf("result of %d bytes exceeds %d", resultLen, maxResultSize)
+	}
+	raw, ok := module.Memory().Read(resultPtr, resultLen)
+	if !ok {
+		return nil, fmt.Errorf("%s returned an out-of-range result", checkExport)
+	}
+
+	var result Result
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("invalid result: %w", err)
+	}
+	return &result, nil
+}
+
+// callError reports a failed call, naming the time limit when it was the cause
+func (r *Rule) callError(ctx context.Context, call string, err error) error {
+	var exit *sys.ExitError
+	if errors.As(err, &exit) && exit.ExitCode() == sys.ExitCodeDeadlineExceeded || ctx.Err() != nil {
+		return fmt.Errorf("%s exceeded the %s time limit", call, r.set.limits.Timeout)
+	}
+	return fmt.Errorf("%s failed: %w", call, err)
+}
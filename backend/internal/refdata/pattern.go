@@ -0,0 +1,68 @@
+package refdata
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Patterns match codes exactly (93000), by prefix (992*) or by inclusive
+// range (27000-27899, R00.0-R00.9). ICD-10 decimal points and case are
+// ignored on both sides.
+type Patterns []pattern
+
+type pattern struct {
+	low, high string
+	prefix    bool
+}
+
+// CompilePatterns parses code patterns, skipping blank entries
+func CompilePatterns(values []string) (Patterns, error) {
+	patterns := make(Patterns, 0, len(values))
+
+	for _, value := range values {
+		value = normalize(value)
+		switch {
+		case value == "":
+			continue
+		case strings.HasSuffix(value, "*"):
+			patterns = append(patterns, pattern{low: strings.TrimSuffix(value, "*"), prefix: true})
+		case strings.Contains(value, "-"):
+			bounds := strings.SplitN(value, "-", 2)
+			if bounds[0] == "" || bounds[1] == "" || bounds[0] > bounds[1] {
+				return nil, fmt.Errorf("invalid range %q", value)
+			}
+			patterns = append(patterns, pattern{low: bounds[0], high: bounds[1]})
+		default:
+			patterns = append(patterns, pattern{low: value, high: value})
+		}
+	}
+
+	return patterns, nil
+}
+
+// Match reports whether any pattern matches the code
+func (ps Patterns) Match(code string) bool {
+	code = normalize(code)
+	for _, p := range ps {
+		if p.match(code) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p pattern) match(code string) bool {
+	if p.prefix {
+		return strings.HasPrefix(code, p.low)
+	}
+	// A range bound covers its own subcodes, so R00.0-R00.9 includes R00.91
+	return code >= p.low && (code <= p.high || strings.HasPrefix(code, p.high))
+}
+
+// normalize upper-cases a code and drops ICD-10 decimal points so codes and
+// patterns compare the same way with or without them
+func normalize(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, ".", "")
+	return strings.ReplaceAll(code, " ", "")
+}
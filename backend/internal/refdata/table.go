@@ -0,0 +1,170 @@
+// Package refdata reads the versioned reference tables (code sets, fee
+// schedules, edit tables) that the verifier checks claims against.
+package refdata
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Row is a single CSV record keyed by lower-cased header name
+type Row map[string]string
+
+// Get returns the trimmed value of a column, or "" if it is absent
+func (r Row) Get(column string) string {
+	return strings.TrimSpace(r[column])
+}
+
+// Table is a CSV reference file loaded into memory
+type Table struct {
+	Path    string
+	Version string // file name without extension, e.g. "2026" or "FY2026"
+	Rows    []Row
+}
+
+// ReadCSV loads a CSV file with a header row. Lines starting with '#' are
+// treated as comments so data files can carry their provenance.
+func ReadCSV(path string, required ...string) (*Table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to read header: %w", path, err)
+	}
+	for i, name := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(name))
+	}
+
+	for _, col := range required {
+		found := false
+		for _, name := range header {
+			if name == col {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%s: missing required column %q", path, col)
+		}
+	}
+
+	table := &Table{
+		Path:    path,
+		Version: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		row := make(Row, len(header))
+		for i, value := range record {
+			if i < len(header) {
+				row[header[i]] = value
+			}
+		}
+		table.Rows = append(table.Rows, row)
+	}
+
+	return table, nil
+}
+
+// ListFiles returns the files in dir with the given extension, sorted by name
+func ListFiles(dir, ext string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ext) {
+			continue
+		}
+		files = append(files, filepath.Join(dir, entry.Name()))
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// ParseDate parses the date formats used by CMS and AMA distribution files
+// (YYYY-MM-DD or YYYYMMDD). An empty value yields the zero time.
+func ParseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse("20060102", value)
+}
+
+// Period is the window during which a reference entry is in force. Zero
+// bounds are open-ended.
+type Period struct {
+	Effective   time.Time
+	Termination time.Time
+}
+
+// MarshalJSON renders the period as dates, omitting open bounds
+func (p Period) MarshalJSON() ([]byte, error) {
+	out := map[string]string{}
+	if !p.Effective.IsZero() {
+		out["effective_date"] = p.Effective.Format("2006-01-02")
+	}
+	if !p.Termination.IsZero() {
+		out["termination_date"] = p.Termination.Format("2006-01-02")
+	}
+	return json.Marshal(out)
+}
+
+// ParsePeriod reads the effective and termination columns of a row
+func ParsePeriod(row Row, effectiveCol, terminationCol string) (Period, error) {
+	effective, err := ParseDate(row.Get(effectiveCol))
+	if err != nil {
+		return Period{}, fmt.Errorf("invalid %s: %w", effectiveCol, err)
+	}
+	termination, err := ParseDate(row.Get(terminationCol))
+	if err != nil {
+		return Period{}, fmt.Errorf("invalid %s: %w", terminationCol, err)
+	}
+	return Period{Effective: effective, Termination: termination}, nil
+}
+
+// Contains reports whether t falls inside the period. A zero t matches any
+// period, which lets callers check existence when no date is known.
+func (p Period) Contains(t time.Time) bool {
+	if t.IsZero() {
+		return true
+	}
+	if !p.Effective.IsZero() && t.Before(p.Effective) {
+		return false
+	}
+	if !p.Termination.IsZero() && t.After(p.Termination) {
+		return false
+	}
+	return true
+}
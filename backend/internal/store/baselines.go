@@ -0,0 +1,52 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/saintparish4/apx/internal/anomaly"
+)
+
+// GetProviderBaseline returns the rolling billing baseline for a provider NPI
+func (s *Store) GetProviderBaseline(ctx context.Context, providerNPI string) (*anomaly.Baseline, error) {
+	var raw []byte
+	err := s.db.QueryRowContext(ctx,
+		`SELECT baseline FROM provider_baselines WHERE provider_npi = $1`,
+		providerNPI,
+	).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query provider baseline: %w", err)
+	}
+
+	var baseline anomaly.Baseline
+	if err := json.Unmarshal(raw, &baseline); err != nil {
+		return nil, fmt.Errorf("failed to decode provider baseline: %w", err)
+	}
+
+	return &baseline, nil
+}
+
+// SaveProviderBaseline stores a provider's updated baseline
+func (s *Store) SaveProviderBaseline(ctx context.Context, baseline *anomaly.Baseline) error {
+	raw, err := json.Marshal(baseline)
+	if err != nil {
+		return fmt.Errorf("failed to encode provider baseline: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO provider_baselines (provider_npi, baseline, updated_at)
+		 VALUES ($1, $2, $3)
+		 ON CONFLICT (provider_npi) DO UPDATE SET baseline = EXCLUDED.baseline, updated_at = EXCLUDED.updated_at`,
+		baseline.ProviderNPI, raw, baseline.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save provider baseline: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/saintparish4/apx/internal/domain"
+)
+
+// fingerprintColumns are read by scanFingerprints
+const fingerprintColumns = `claim_id, content_hash, patient_id, provider_npi, service_date::TEXT,
+	array_to_string(procedure_codes, ','), recorded_at`
+
+// FindClaimFingerprints returns indexed claims for a patient and service date
+func (s *Store) FindClaimFingerprints(ctx context.Context, patientID, serviceDate string) ([]*domain.ClaimFingerprint, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+fingerprintColumns+`
+		 FROM claim_fingerprints
+		 WHERE patient_id = $1 AND service_date = $2::DATE
+		 ORDER BY recorded_at`,
+		patientID, serviceDate,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query claim fingerprints: %w", err)
+	}
+	return scanFingerprints(rows)
+}
+
+// RecordClaimFingerprint adds a processed claim to the duplicate index and
+// returns the claims indexed before it for the same patient and service
+// date. Claims for a patient and date are recorded one at a time under an
+// advisory lock, so of two copies processed at once the later sees the
+// earlier. A claim recorded again gets the claims indexed before its first
+// recording.
+func (s *Store) RecordClaimFingerprint(ctx context.Context, fp *domain.ClaimFingerprint) ([]*domain.ClaimFingerprint, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		`SELECT pg_advisory_xact_lock(hashtext('claim_fingerprints|' || $1::TEXT || '|' || $2::TEXT))`,
+		fp.PatientID, fp.ServiceDate,
+	); err != nil {
+		return nil, fmt.Errorf("failed to lock claim fingerprints: %w", err)
+	}
+
+	// clock_timestamp, unlike NOW, is read once the lock is held, so claims
+	// are ordered as they were recorded
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO claim_fingerprints
+			(claim_id, content_hash, patient_id, provider_npi, service_date, procedure_codes, recorded_at)
+		 VALUES ($1, $2, $3, $4, $5::DATE, $6, clock_timestamp())
+		 ON CONFLICT (claim_id) DO NOTHING`,
+		fp.ClaimID, fp.ContentHash, fp.PatientID, fp.ProviderNPI, fp.ServiceDate, fp.ProcedureCodes,
+	); err != nil {
+		return nil, fmt.Errorf("failed to record claim fingerprint: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT `+fingerprintColumns+`
+		 FROM claim_fingerprints
+		 WHERE patient_id = $1 AND service_date = $2::DATE
+		   AND recorded_at < (SELECT recorded_at FROM claim_fingerprints WHERE claim_id = $3)
+		 ORDER BY recorded_at`,
+		fp.PatientID, fp.ServiceDate, fp.ClaimID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query claim fingerprints: %w", err)
+	}
+	prior, err := scanFingerprints(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to record claim fingerprint: %w", err)
+	}
+	return prior, nil
+}
+
+func scanFingerprints(rows *sql.Rows) ([]*domain.ClaimFingerprint, error) {
+	defer rows.Close()
+
+	var fingerprints []*domain.ClaimFingerprint
+	for rows.Next() {
+		var (
+			fp    domain.ClaimFingerprint
+			codes sql.NullString
+		)
+		if err := rows.Scan(&fp.ClaimID, &fp.ContentHash, &fp.PatientID, &fp.ProviderNPI,
+			&fp.ServiceDate, &codes, &fp.RecordedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan claim fingerprint: %w", err)
+		}
+		if codes.String != "" {
+			fp.ProcedureCodes = strings.Split(codes.String, ",")
+		}
+		fingerprints = append(fingerprints, &fp)
+	}
+
+	return fingerprints, rows.Err()
+}
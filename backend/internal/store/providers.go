@@ -0,0 +1,100 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/saintparish4/apx/internal/npi"
+)
+
+// GetProviderNPI returns the NPI a provider registered with, keyed by wallet
+func (s *Store) GetProviderNPI(ctx context.Context, wallet common.Address) (string, error) {
+	var registered sql.NullString
+	err := s.db.QueryRowContext(ctx,
+		`SELECT npi FROM providers WHERE LOWER(wallet_address) = LOWER($1)`,
+		wallet.Hex(),
+	).Scan(&registered)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query provider NPI: %w", err)
+	}
+	if !registered.Valid || strings.TrimSpace(registered.String) == "" {
+		return "", ErrNotFound
+	}
+
+	return strings.TrimSpace(registered.String), nil
+}
+
+// GetNPPESRecord returns the imported NPPES record for an NPI
+func (s *Store) GetNPPESRecord(ctx context.Context, id string) (*npi.Record, error) {
+	var (
+		rec          npi.Record
+		state        sql.NullString
+		taxonomy     sql.NullString
+		deactivation sql.NullTime
+		reactivation sql.NullTime
+	)
+
+	err := s.db.QueryRowContext(ctx,
+		`SELECT npi, entity_type, name, state, taxonomy_code, deactivation_date, reactivation_date
+		 FROM nppes_providers WHERE npi = $1`,
+		id,
+	).Scan(&rec.NPI, &rec.EntityType, &rec.Name, &state, &taxonomy, &deactivation, &reactivation)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query NPPES record: %w", err)
+	}
+
+	rec.State = state.String
+	rec.TaxonomyCode = taxonomy.String
+	rec.DeactivationDate = deactivation.Time
+	rec.ReactivationDate = reactivation.Time
+
+	return &rec, nil
+}
+
+// UpsertNPPESRecords inserts or refreshes a batch of NPPES records in a
+// single transaction
+func (s *Store) UpsertNPPESRecords(ctx context.Context, records []*npi.Record) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO nppes_providers
+			(npi, entity_type, name, state, taxonomy_code, deactivation_date, reactivation_date, imported_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, $7, NOW())
+		ON CONFLICT (npi) DO UPDATE SET
+			entity_type       = EXCLUDED.entity_type,
+			name              = EXCLUDED.name,
+			state             = EXCLUDED.state,
+			taxonomy_code     = EXCLUDED.taxonomy_code,
+			deactivation_date = EXCLUDED.deactivation_date,
+			reactivation_date = EXCLUDED.reactivation_date,
+			imported_at       = NOW()`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare upsert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, rec := range records {
+		if _, err := stmt.ExecContext(ctx,
+			rec.NPI, rec.EntityType, rec.Name, rec.State, rec.TaxonomyCode,
+			nullTime(rec.DeactivationDate), nullTime(rec.ReactivationDate),
+		); err != nil {
+			return fmt.Errorf("failed to upsert NPI %s: %w", rec.NPI, err)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,241 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/saintparish4/apx/internal/domain"
+)
+
+const reviewColumns = `claim_id, ipfs_cid, provider_address, result, status, reviewer, notes,
+	approved, reason, deadline, window_closes_at, created_at, decided_at, vote_tx_hash, submitted_at`
+
+// EnqueueReview parks a claim in the review queue. Re-enqueueing a claim
+// that is already queued is a no-op.
+func (s *Store) EnqueueReview(ctx context.Context, review *domain.Review) error {
+	result, err := json.Marshal(review.Result)
+	if err != nil {
+		return fmt.Errorf("failed to encode review result: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO review_queue (claim_id, ipfs_cid, provider_address, result, status, deadline, window_closes_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 ON CONFLICT (claim_id) DO NOTHING`,
+		review.ClaimID, review.IPFSCID, review.Provider.Hex(), result, domain.ReviewPending,
+		review.Deadline, review.WindowClosesAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue review: %w", err)
+	}
+	return nil
+}
+
+// GetReview returns a review by claim ID
+func (s *Store) GetReview(ctx context.Context, claimID string) (*domain.Review, error) {
+	row := s.db.QueryRowContext(ctx,
+		`SELECT `+reviewColumns+` FROM review_queue WHERE claim_id = $1`, claimID)
+	return scanReview(row)
+}
+
+// ListReviews returns reviews in a status (all when empty), most urgent first
+func (s *Store) ListReviews(ctx context.Context, status domain.ReviewStatus, limit, offset int) ([]*domain.Review, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+reviewColumns+` FROM review_queue
+		 WHERE $1 = '' OR status = $1
+		 ORDER BY deadline, created_at
+		 LIMIT $2 OFFSET $3`,
+		string(status), limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reviews: %w", err)
+	}
+	defer rows.Close()
+
+	reviews := []*domain.Review{}
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+
+	return reviews, rows.Err()
+}
+
+// ClaimReview assigns an open review to a reviewer. A review claimed by
+// someone else, decided or expired returns ErrConflict.
+func (s *Store) ClaimReview(ctx context.Context, claimID, reviewer string) (*domain.Review, error) {
+	return s.updateReview(ctx, claimID,
+		`UPDATE review_queue SET status = 'claimed', reviewer = $2, updated_at = NOW()
+		 WHERE claim_id = $1
+		   AND (status = 'pending' OR (status = 'claimed' AND reviewer = $2))
+		   AND deadline > NOW()
+		 RETURNING `+reviewColumns,
+		claimID, reviewer,
+	)
+}
+
+// AddReviewNote appends a reviewer annotation to an open review
+func (s *Store) AddReviewNote(ctx context.Context, claimID string, note domain.ReviewNote) (*domain.Review, error) {
+	raw, err := json.Marshal([]domain.ReviewNote{note})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode review note: %w", err)
+	}
+
+	return s.updateReview(ctx, claimID,
+		`UPDATE review_queue SET notes = notes || $2::JSONB, updated_at = NOW()
+		 WHERE claim_id = $1 AND status IN ('pending', 'claimed')
+		 RETURNING `+reviewColumns,
+		claimID, raw,
+	)
+}
+
+// DecideReview records a reviewer's decision on an open review before its
+// deadline. Reviews claimed by another reviewer return ErrConflict.
+func (s *Store) DecideReview(ctx context.Context, claimID, reviewer string, approved bool, reason string) (*domain.Review, error) {
+	return s.updateReview(ctx, claimID,
+		`UPDATE review_queue
+		 SET status = 'decided', reviewer = $2, approved = $3, reason = $4, decided_at = NOW(), updated_at = NOW()
+		 WHERE claim_id = $1
+		   AND (status = 'pending' OR (status = 'claimed' AND reviewer = $2))
+		   AND deadline > NOW()
+		 RETURNING `+reviewColumns,
+		claimID, reviewer, approved, reason,
+	)
+}
+
+// DecideOverdueReview records the automatic verdict as the decision of an
+// open review past its deadline, so a vote is still cast before the window
+// closes. The reviewer, who did not decide, is cleared. Reviews in any other
+// state return ErrConflict.
+func (s *Store) DecideOverdueReview(ctx context.Context, claimID string, approved bool, reason string) (*domain.Review, error) {
+	return s.updateReview(ctx, claimID,
+		`UPDATE review_queue
+		 SET status = 'decided', reviewer = NULL, approved = $2, reason = $3, decided_at = NOW(), updated_at = NOW()
+		 WHERE claim_id = $1
+		   AND status IN ('pending', 'claimed')
+		   AND deadline <= NOW() AND window_closes_at > NOW()
+		 RETURNING `+reviewColumns,
+		claimID, approved, reason,
+	)
+}
+
+// reviewSubmissionLease bounds how long a submission may hold a review
+const reviewSubmissionLease = "5 minutes"
+
+// BeginReviewSubmission moves a decided review to submitting, so only one
+// caller votes on it. A submission left in progress longer than
+// reviewSubmissionLease, e.g. by a crash, may be taken over. Reviews in any
+// other state return ErrConflict.
+func (s *Store) BeginReviewSubmission(ctx context.Context, claimID string) (*domain.Review, error) {
+	return s.updateReview(ctx, claimID,
+		`UPDATE review_queue SET status = 'submitting', updated_at = NOW()
+		 WHERE claim_id = $1
+		   AND (status = 'decided' OR (status = 'submitting' AND updated_at <= NOW() - $2::INTERVAL))
+		   AND window_closes_at > NOW()
+		 RETURNING `+reviewColumns,
+		claimID, reviewSubmissionLease,
+	)
+}
+
+// ReleaseReviewSubmission returns a review whose vote failed to decided, to
+// be retried
+func (s *Store) ReleaseReviewSubmission(ctx context.Context, claimID string) (*domain.Review, error) {
+	return s.updateReview(ctx, claimID,
+		`UPDATE review_queue SET status = 'decided', updated_at = NOW()
+		 WHERE claim_id = $1 AND status = 'submitting'
+		 RETURNING `+reviewColumns,
+		claimID,
+	)
+}
+
+// MarkReviewSubmitted records the transaction that carried a review's vote
+func (s *Store) MarkReviewSubmitted(ctx context.Context, claimID string, txHash common.Hash) (*domain.Review, error) {
+	return s.updateReview(ctx, claimID,
+		`UPDATE review_queue SET status = 'submitted', vote_tx_hash = $2, submitted_at = NOW(), updated_at = NOW()
+		 WHERE claim_id = $1 AND status = 'submitting'
+		 RETURNING `+reviewColumns,
+		claimID, txHash.Hex(),
+	)
+}
+
+// ExpireReviews closes reviews whose vote missed the verification window
+func (s *Store) ExpireReviews(ctx context.Context) (int64, error) {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE review_queue SET status = 'expired', updated_at = NOW()
+		 WHERE status IN ('pending', 'claimed', 'decided', 'submitting') AND window_closes_at <= NOW()`,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire reviews: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// updateReview runs a conditional update, telling a missing review apart
+// from one in the wrong state
+func (s *Store) updateReview(ctx context.Context, claimID, query string, args ...interface{}) (*domain.Review, error) {
+	review, err := scanReview(s.db.QueryRowContext(ctx, query, args...))
+	if !errors.Is(err, ErrNotFound) {
+		return review, err
+	}
+
+	if _, err := s.GetReview(ctx, claimID); err != nil {
+		return nil, err
+	}
+	return nil, ErrConflict
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanReview(row rowScanner) (*domain.Review, error) {
+	var (
+		review               domain.Review
+		provider, status     string
+		result, notes        []byte
+		reviewer, reason     sql.NullString
+		txHash               sql.NullString
+		approved             sql.NullBool
+		decidedAt, submitted sql.NullTime
+	)
+
+	err := row.Scan(&review.ClaimID, &review.IPFSCID, &provider, &result, &status, &reviewer, &notes,
+		&approved, &reason, &review.Deadline, &review.WindowClosesAt, &review.CreatedAt, &decidedAt, &txHash, &submitted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan review: %w", err)
+	}
+
+	review.Provider = common.HexToAddress(provider)
+	review.Status = domain.ReviewStatus(status)
+	review.Reviewer = reviewer.String
+	review.Reason = reason.String
+	review.VoteTxHash = txHash.String
+	if approved.Valid {
+		review.Approved = &approved.Bool
+	}
+	if decidedAt.Valid {
+		review.DecidedAt = &decidedAt.Time
+	}
+	if submitted.Valid {
+		review.SubmittedAt = &submitted.Time
+	}
+
+	if err := json.Unmarshal(result, &review.Result); err != nil {
+		return nil, fmt.Errorf("failed to decode review result: %w", err)
+	}
+	if err := json.Unmarshal(notes, &review.Notes); err != nil {
+		return nil, fmt.Errorf("failed to decode review notes: %w", err)
+	}
+
+	return &review, nil
+}
@@ -0,0 +1,65 @@
+// Package store provides access to the off-chain PostgreSQL database
+// described in docker/init.sql.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // registers the "pgx" driver
+)
+
+// ErrNotFound is returned when a lookup matches no rows
+var ErrNotFound = errors.New("not found")
+
+// ErrConflict is returned when a conditional update finds the row in a state
+// that does not allow it
+var ErrConflict = errors.New("conflicting state")
+
+// Store wraps the database connection pool
+type Store struct {
+	db *sql.DB
+}
+
+// Open connects to the database and verifies the connection
+func Open(ctx context.Context, databaseURL string) (*Store, error) {
+	db, err := sql.Open("pgx", databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	db.SetMaxOpenConns(10)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(30 * time.Minute)
+
+	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(pingCtx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// HealthCheck checks if the database is reachable
+func (s *Store) HealthCheck(ctx context.Context) error {
+	if err := s.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("database health check failed: %w", err)
+	}
+	return nil
+}
+
+// Close closes the connection pool
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// nullTime converts a zero time to SQL NULL
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/saintparish4/apx/internal/domain"
+)
+
+// SaveVerdict stores the node's validation result for an on-chain claim,
+// replacing any earlier verdict for the same claim
+func (s *Store) SaveVerdict(ctx context.Context, verdict *domain.Verdict) error {
+	raw, err := json.Marshal(verdict.Result)
+	if err != nil {
+		return fmt.Errorf("failed to encode verdict: %w", err)
+	}
+
+	var version, hash string
+	if info := verdict.Result.Ruleset; info != nil {
+		version, hash = info.Version, info.Hash
+	}
+
+	var amount, dataHash sql.NullString
+	if verdict.Amount != nil {
+		amount = sql.NullString{String: verdict.Amount.String(), Valid: true}
+	}
+	if verdict.DataHash != nil {
+		dataHash = sql.NullString{String: verdict.DataHash.Hex(), Valid: true}
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO claim_verdicts
+		    (claim_id, ipfs_cid, provider_address, amount, data_hash, ruleset_version, ruleset_hash, approved, score, result)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		 ON CONFLICT (claim_id) DO UPDATE SET
+		    ipfs_cid = EXCLUDED.ipfs_cid,
+		    provider_address = EXCLUDED.provider_address,
+		    amount = EXCLUDED.amount,
+		    data_hash = EXCLUDED.data_hash,
+		    ruleset_version = EXCLUDED.ruleset_version,
+		    ruleset_hash = EXCLUDED.ruleset_hash,
+		    approved = EXCLUDED.approved,
+		    score = EXCLUDED.score,
+		    result = EXCLUDED.result,
+		    validated_at = NOW()`,
+		verdict.ClaimID, verdict.IPFSCID, verdict.Provider.Hex(), amount, dataHash, version, hash,
+		verdict.Result.Approved, verdict.Result.Score, raw,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save verdict: %w", err)
+	}
+	return nil
+}
+
+// GetVerdict returns the stored verdict for a claim ID (0x-prefixed hex)
+func (s *Store) GetVerdict(ctx context.Context, claimID string) (*domain.Verdict, error) {
+	var (
+		verdict  domain.Verdict
+		provider string
+		amount   sql.NullString
+		dataHash sql.NullString
+		raw      []byte
+	)
+	err := s.db.QueryRowContext(ctx,
+		`SELECT claim_id, ipfs_cid, provider_address, amount::TEXT, data_hash, result FROM claim_verdicts WHERE claim_id = $1`,
+		claimID,
+	).Scan(&verdict.ClaimID, &verdict.IPFSCID, &provider, &amount, &dataHash, &raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query verdict: %w", err)
+	}
+
+	verdict.Provider = common.HexToAddress(provider)
+	if amount.Valid {
+		verdict.Amount, _ = new(big.Int).SetString(amount.String, 10)
+	}
+	if dataHash.Valid {
+		hash := common.HexToHash(dataHash.String)
+		verdict.DataHash = &hash
+	}
+	if err := json.Unmarshal(raw, &verdict.Result); err != nil {
+		return nil, fmt.Errorf("failed to decode verdict: %w", err)
+	}
+
+	return &verdict, nil
+}
@@ -0,0 +1,115 @@
+package verifier
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/saintparish4/apx/internal/anomaly"
+	"github.com/saintparish4/apx/internal/domain"
+	"github.com/saintparish4/apx/internal/store"
+)
+
+// BaselineStore persists rolling per-provider billing baselines
+type BaselineStore interface {
+	GetProviderBaseline(ctx context.Context, providerNPI string) (*anomaly.Baseline, error)
+	SaveProviderBaseline(ctx context.Context, baseline *anomaly.Baseline) error
+}
+
+// WithAnomalyScoring scores claims against their provider's billing
+// baseline, with thresholds from the ruleset
+func WithAnomalyScoring(baselines BaselineStore) Option {
+	return func(n *Node) {
+		n.baselines = baselines
+	}
+}
+
+// anomalyFactors compares a claim with its provider's baseline. Baselines
+// only exist in their current state, so replays skip them.
+func (n *Node) anomalyFactors(ctx context.Context, sub *Submission) []anomaly.Factor {
+	if n.baselines == nil || sub.ProviderNPI == "" || !sub.AsOf.IsZero() {
+		return nil
+	}
+
+	baseline, err := n.baselines.GetProviderBaseline(ctx, sub.ProviderNPI)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil // No history yet
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("npi", sub.ProviderNPI).Msg("Provider baseline lookup failed")
+		return nil
+	}
+
+	return baseline.Evaluate(observe(sub), time.Now(), sub.ruleset.Anomaly)
+}
+
+// updateBaseline folds an on-chain claim into its provider's baseline. Only
+// valid claims whose NPI is the one registered for the submitting wallet
+// count, so a provider's history can't be shaped by claims billed under its
+// NPI from elsewhere.
+func (n *Node) updateBaseline(ctx context.Context, sub *Submission, result *domain.ValidationResult) {
+	if n.baselines == nil || n.providers == nil || sub.ProviderNPI == "" || !result.Valid {
+		return
+	}
+
+	registered, err := n.providers.GetProviderNPI(ctx, sub.Provider)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		log.Warn().Err(err).Str("provider", sub.Provider.Hex()).Msg("Provider NPI lookup failed")
+	}
+	if err != nil || registered != sub.ProviderNPI {
+		return
+	}
+
+	// Claims are processed concurrently; serialize read-modify-write cycles
+	n.baselineMu.Lock()
+	defer n.baselineMu.Unlock()
+
+	baseline, err := n.baselines.GetProviderBaseline(ctx, sub.ProviderNPI)
+	if errors.Is(err, store.ErrNotFound) {
+		baseline = anomaly.NewBaseline(sub.ProviderNPI)
+	} else if err != nil {
+		log.Warn().Err(err).Str("npi", sub.ProviderNPI).Msg("Provider baseline lookup failed")
+		return
+	}
+
+	baseline.Observe(observe(sub), time.Now(), n.ruleset.Anomaly)
+
+	if err := n.baselines.SaveProviderBaseline(ctx, baseline); err != nil {
+		log.Warn().Err(err).Str("npi", sub.ProviderNPI).Msg("Failed to save provider baseline")
+	}
+}
+
+// anomalyFinding locates an anomalous feature at the claim field it concerns
+func anomalyFinding(sub *Submission, factor anomaly.Factor, penalty float64) domain.Finding {
+	finding := domain.Finding{
+		Rule:        "provider_anomaly." + factor.Name,
+		Severity:    "warning",
+		Value:       factor.Value,
+		Message:     factor.Detail,
+		ScoreImpact: penalty,
+	}
+
+	switch factor.Name {
+	case "amount_zscore":
+		finding.Path, finding.Value = "billed_amount", sub.BilledAmount
+	case "unusual_codes", "high_level_em_share":
+		finding.Path = "procedure_codes"
+	}
+
+	return finding
+}
+
+func observe(sub *Submission) anomaly.Observation {
+	obs := anomaly.Observation{}
+	if sub.BilledAmount != nil {
+		obs.Amount = sub.BilledAmount.Float64()
+	}
+
+	lines, _ := procedureLines(sub.ClaimData)
+	for _, line := range lines {
+		obs.Codes = append(obs.Codes, line.Code)
+	}
+
+	return obs
+}
@@ -0,0 +1,169 @@
+package verifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/saintparish4/apx/internal/attachment"
+	"github.com/saintparish4/apx/internal/ipfs"
+)
+
+// documentFetchTimeout bounds the retrieval of a single supporting document
+const documentFetchTimeout = 15 * time.Second
+
+// WithDocumentRequirements checks that procedures are backed by the
+// supporting documents the requirements declare
+func WithDocumentRequirements(requirements *attachment.Requirements) Option {
+	return func(n *Node) {
+		n.documents = requirements
+	}
+}
+
+// supportingDocument is a document referenced by a claim and what the
+// verifier made of it
+type supportingDocument struct {
+	Path       string
+	CID        string
+	ReportType string // empty for CIDs listed without a type
+	MediaType  string
+	Problem    string // why the document is unusable; empty when it is readable
+}
+
+// supportingDocuments retrieves and decrypts each referenced document once
+// per submission. Without an IPFS service documents cannot be inspected and
+// are taken as readable.
+func (n *Node) supportingDocuments(ctx context.Context, sub *Submission) []supportingDocument {
+	if sub.documents != nil {
+		return sub.documents
+	}
+
+	documents := []supportingDocument{}
+	for i, cid := range sub.SupportingDocuments {
+		documents = append(documents, supportingDocument{Path: fieldPath("supporting_documents", i), CID: cid})
+	}
+	for i, attached := range sub.Attachments {
+		documents = append(documents, supportingDocument{
+			Path:       fieldPath("attachments", i) + ".cid",
+			CID:        attached.CID,
+			ReportType: strings.ToUpper(strings.TrimSpace(attached.ReportType)),
+		})
+	}
+
+	// A document listed twice is only fetched once
+	inspected := map[string]supportingDocument{}
+	for i, document := range documents {
+		if previous, ok := inspected[document.CID]; ok {
+			documents[i].MediaType, documents[i].Problem = previous.MediaType, previous.Problem
+			continue
+		}
+		documents[i].MediaType, documents[i].Problem = n.inspectDocument(ctx, document.CID)
+		inspected[document.CID] = documents[i]
+	}
+
+	sub.documents = documents
+	return documents
+}
+
+// inspectDocument fetches a document and identifies its file format
+func (n *Node) inspectDocument(ctx context.Context, cid string) (mediaType, problem string) {
+	if cid == "" {
+		return "", "has no CID"
+	}
+	if n.ipfsService == nil {
+		return "", ""
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, documentFetchTimeout)
+	defer cancel()
+
+	content, err := n.ipfsService.RetrieveRaw(ctx, cid, true)
+	if errors.Is(err, ipfs.ErrDecryption) {
+		return "", "could not be decrypted"
+	}
+	if err != nil {
+		return "", fmt.Sprintf("could not be retrieved: %v", err)
+	}
+
+	mediaType = attachment.MediaType(content)
+	if !attachment.AllowedMediaType(mediaType) {
+		return mediaType, fmt.Sprintf("is %s, not one of %s", mediaType, strings.Join(attachment.MediaTypes, ", "))
+	}
+	return mediaType, ""
+}
+
+func (n *Node) checkSupportingDocuments(ctx context.Context, data *Submission) []Violation {
+	violations := []Violation{}
+
+	for i, attached := range data.Attachments {
+		if _, ok := attachment.ReportTypes[strings.ToUpper(strings.TrimSpace(attached.ReportType))]; !ok {
+			violations = append(violations, violation(fieldPath("attachments", i)+".report_type", attached.ReportType,
+				"Unknown report type: %q", attached.ReportType)...)
+		}
+	}
+
+	for _, document := range n.supportingDocuments(ctx, data) {
+		if document.Problem != "" {
+			violations = append(violations, violation(document.Path, document.CID,
+				"Supporting document %s %s", document.CID, document.Problem)...)
+		}
+	}
+
+	return violations
+}
+
+// checkDocumentRequirements returns a check that every procedure is backed
+// by a readable document of a kind its requirements of the given severity
+// accept
+func (n *Node) checkDocumentRequirements(severity string) func(context.Context, *Submission) []Violation {
+	return func(ctx context.Context, data *Submission) []Violation {
+		if !n.documents.Loaded() {
+			return nil
+		}
+
+		// Malformed codes are left out; checkProcedureCodes reports them
+		lines, _ := procedureLines(data.ClaimData)
+
+		// Requirements in load order, with the procedures that need them
+		var requirements []*attachment.Requirement
+		codes := map[*attachment.Requirement][]string{}
+		for _, line := range lines {
+			for _, requirement := range n.documents.ForProcedure(line.Code) {
+				if requirement.Severity != severity {
+					continue
+				}
+				if _, seen := codes[requirement]; !seen {
+					requirements = append(requirements, requirement)
+				}
+				codes[requirement] = append(codes[requirement], line.Code)
+			}
+		}
+		if len(requirements) == 0 {
+			return nil
+		}
+
+		documents := n.supportingDocuments(ctx, data)
+		violations := []Violation{}
+
+		for _, requirement := range requirements {
+			if !satisfied(requirement, documents) {
+				violations = append(violations, violation("attachments", codes[requirement],
+					"No readable %s for %s (%s)", requirement.Describe(), strings.Join(codes[requirement], ", "), requirement.ID)...)
+			}
+		}
+
+		return violations
+	}
+}
+
+// satisfied reports whether a readable document meets the requirement
+func satisfied(requirement *attachment.Requirement, documents []supportingDocument) bool {
+	for _, document := range documents {
+		if document.Problem == "" && requirement.SatisfiedBy(document.ReportType) {
+			return true
+		}
+	}
+	return false
+}
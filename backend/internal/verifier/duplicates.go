@@ -0,0 +1,252 @@
+package verifier
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/saintparish4/apx/internal/domain"
+)
+
+// ClaimIndex persists fingerprints of processed claims for duplicate detection
+type ClaimIndex interface {
+	FindClaimFingerprints(ctx context.Context, patientID, serviceDate string) ([]*domain.ClaimFingerprint, error)
+	RecordClaimFingerprint(ctx context.Context, fp *domain.ClaimFingerprint) ([]*domain.ClaimFingerprint, error)
+}
+
+// WithClaimIndex enables cross-claim duplicate detection
+func WithClaimIndex(index ClaimIndex) Option {
+	return func(n *Node) {
+		n.claimIndex = index
+	}
+}
+
+func (n *Node) checkDuplicateClaim(ctx context.Context, data *Submission) []Violation {
+	prior, fp := n.priorClaims(ctx, data)
+
+	for _, other := range prior {
+		if other.ContentHash == fp.ContentHash {
+			return violation("", other.ClaimID, "Duplicate of claim %s submitted %s",
+				other.ClaimID, other.RecordedAt.Format(time.RFC3339))
+		}
+	}
+
+	return nil
+}
+
+func (n *Node) checkPossibleDuplicate(ctx context.Context, data *Submission) []Violation {
+	prior, fp := n.priorClaims(ctx, data)
+	violations := []Violation{}
+
+	for _, other := range prior {
+		if other.ContentHash == fp.ContentHash {
+			continue // Reported by checkDuplicateClaim
+		}
+
+		switch {
+		case other.ProviderNPI == fp.ProviderNPI:
+			if overlap := intersect(fp.ProcedureCodes, other.ProcedureCodes); len(overlap) > 0 {
+				violations = append(violations, violation("procedure_codes", other.ClaimID,
+					"Possible duplicate of claim %s for the same patient and service date (same provider, overlapping codes %s)",
+					other.ClaimID, strings.Join(overlap, ", "))...)
+			}
+		case sameCodes(fp.ProcedureCodes, other.ProcedureCodes):
+			violations = append(violations, violation("provider_npi", other.ClaimID,
+				"Possible duplicate of claim %s for the same patient and service date (billed by NPI %s with the same codes)",
+				other.ClaimID, other.ProviderNPI)...)
+		}
+	}
+
+	return violations
+}
+
+// priorClaims returns previously indexed claims for the same patient and
+// service date, excluding the submission itself. On-chain claims are indexed
+// before validation, which gave their prior claims.
+func (n *Node) priorClaims(ctx context.Context, data *Submission) ([]*domain.ClaimFingerprint, *domain.ClaimFingerprint) {
+	fp := fingerprint(data)
+	if n.claimIndex == nil || fp == nil {
+		return nil, fp
+	}
+	if data.indexed {
+		return data.prior, fp
+	}
+
+	found, err := n.claimIndex.FindClaimFingerprints(ctx, fp.PatientID, fp.ServiceDate)
+	if err != nil {
+		log.Warn().Err(err).Msg("Duplicate index lookup failed")
+		return nil, fp
+	}
+
+	// Replays only see claims indexed before the original validation
+	prior := make([]*domain.ClaimFingerprint, 0, len(found))
+	for _, other := range found {
+		if other.ClaimID == fp.ClaimID {
+			continue
+		}
+		if !data.AsOf.IsZero() && !other.RecordedAt.Before(data.AsOf) {
+			continue
+		}
+		prior = append(prior, other)
+	}
+
+	return prior, fp
+}
+
+// recordFingerprint adds an on-chain claim to the duplicate index, keeping
+// the claims indexed before it for the duplicate checks. Recording first
+// means that of two copies of a claim processed at once, one is caught.
+func (n *Node) recordFingerprint(ctx context.Context, sub *Submission) {
+	if n.claimIndex == nil || sub.ClaimID == ([32]byte{}) {
+		return
+	}
+
+	fp := fingerprint(sub)
+	if fp == nil {
+		return
+	}
+
+	prior, err := n.claimIndex.RecordClaimFingerprint(ctx, fp)
+	if err != nil {
+		log.Warn().Err(err).Str("claim_id", fp.ClaimID).Msg("Failed to record claim fingerprint")
+		return // The checks look up prior claims themselves
+	}
+	sub.prior, sub.indexed = prior, true
+}
+
+// fingerprint derives the duplicate index entry for a submission. It returns
+// nil when the claim lacks the fields needed to compare it.
+func fingerprint(sub *Submission) *domain.ClaimFingerprint {
+	data := sub.ClaimData
+	if data.PatientID == "" || parseServiceDate(data).IsZero() {
+		return nil
+	}
+
+	codes := []string{}
+	lines, _ := procedureLines(data)
+	for _, line := range lines {
+		codes = append(codes, line.Code)
+	}
+	sort.Strings(codes)
+
+	fp := &domain.ClaimFingerprint{
+		ContentHash:    contentHash(data),
+		PatientID:      data.PatientID,
+		ProviderNPI:    data.ProviderNPI,
+		ServiceDate:    data.ServiceDate,
+		ProcedureCodes: codes,
+		RecordedAt:     time.Now().UTC(),
+	}
+	if sub.ClaimID != ([32]byte{}) {
+		fp.ClaimID = "0x" + hex.EncodeToString(sub.ClaimID[:])
+	}
+
+	return fp
+}
+
+// claimContent is the clinical and financial content that makes two claims
+// the same encounter billed twice. Fields are listed explicitly so fields
+// added to ClaimData, such as submission metadata, stay out of the hash
+// until chosen.
+type claimContent struct {
+	PatientID      string `json:"patient_id"`
+	PatientDOBHash string `json:"patient_dob_hash"`
+	ProviderNPI    string `json:"provider_npi"`
+	FacilityID     string `json:"facility_id"`
+	PayerID        string `json:"payer_id"`
+
+	// A corrected claim replaces its original rather than duplicating it
+	OriginalClaimID string `json:"original_claim_id"`
+
+	ClaimType      string               `json:"claim_type"`
+	ServiceDate    string               `json:"service_date"`
+	ProcedureCodes []string             `json:"procedure_codes"`
+	DiagnosisCodes []string             `json:"diagnosis_codes"`
+	PlaceOfService string               `json:"place_of_service"`
+	TypeOfBill     string               `json:"type_of_bill"`
+	AdmissionDate  string               `json:"admission_date"`
+	DischargeDate  string               `json:"discharge_date"`
+	DRGCode        string               `json:"drg_code"`
+	ServiceLines   []domain.ServiceLine `json:"service_lines"`
+
+	BilledAmount     *domain.Money `json:"billed_amount"`
+	AllowedAmount    *domain.Money `json:"allowed_amount"`
+	CopayAmount      *domain.Money `json:"copay_amount"`
+	DeductibleAmount *domain.Money `json:"deductible_amount"`
+}
+
+// contentHash hashes the clinical and financial content of a claim, ignoring
+// code order, so a resubmission of the same encounter hashes identically
+func contentHash(data *domain.ClaimData) string {
+	content := claimContent{
+		PatientID:        data.PatientID,
+		PatientDOBHash:   data.PatientDOBHash,
+		ProviderNPI:      data.ProviderNPI,
+		FacilityID:       data.FacilityID,
+		PayerID:          data.PayerID,
+		OriginalClaimID:  data.OriginalClaimID,
+		ClaimType:        data.ClaimType,
+		ServiceDate:      data.ServiceDate,
+		ProcedureCodes:   sortedCopy(data.ProcedureCodes),
+		DiagnosisCodes:   data.DiagnosisCodes,
+		PlaceOfService:   data.PlaceOfService,
+		TypeOfBill:       data.TypeOfBill,
+		AdmissionDate:    data.AdmissionDate,
+		DischargeDate:    data.DischargeDate,
+		DRGCode:          data.DRGCode,
+		ServiceLines:     sortedServiceLines(data.ServiceLines),
+		BilledAmount:     data.BilledAmount,
+		AllowedAmount:    data.AllowedAmount,
+		CopayAmount:      data.CopayAmount,
+		DeductibleAmount: data.DeductibleAmount,
+	}
+	if len(data.ServiceLines) == 0 {
+		// Diagnosis pointers index the submitted order, so only flat
+		// claims can have their diagnoses reordered
+		content.DiagnosisCodes = sortedCopy(data.DiagnosisCodes)
+	}
+
+	encoded, _ := json.Marshal(content)
+	sum := sha256.Sum256(encoded)
+	return "0x" + hex.EncodeToString(sum[:])
+}
+
+func sortedCopy(values []string) []string {
+	out := append([]string(nil), values...)
+	sort.Strings(out)
+	return out
+}
+
+// intersect returns the codes present in both sorted slices
+func intersect(a, b []string) []string {
+	in := make(map[string]bool, len(b))
+	for _, code := range b {
+		in[code] = true
+	}
+
+	var common []string
+	for _, code := range a {
+		if in[code] && (len(common) == 0 || common[len(common)-1] != code) {
+			common = append(common, code)
+		}
+	}
+	return common
+}
+
+// sameCodes reports whether two sorted code lists are equal
+func sameCodes(a, b []string) bool {
+	if len(a) != len(b) || len(a) == 0 {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
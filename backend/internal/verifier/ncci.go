@@ -0,0 +1,128 @@
+package verifier
+
+import (
+	"context"
+	"sort"
+
+	"github.com/saintparish4/apx/internal/ncci"
+)
+
+// WithNCCIEdits enables NCCI procedure-to-procedure and MUE checks
+func WithNCCIEdits(edits *ncci.Edits) Option {
+	return func(n *Node) {
+		n.ncciEdits = edits
+	}
+}
+
+func (n *Node) checkNCCIProcedurePairs(ctx context.Context, data *Submission) []Violation {
+	if !n.ncciEdits.Loaded() {
+		return nil
+	}
+
+	lines, ok := procedureLines(data.ClaimData)
+	if !ok {
+		return nil // Already checked in checkProcedureCodes
+	}
+
+	serviceDate := parseServiceDate(data.ClaimData)
+	violations := []Violation{}
+	seen := map[string]bool{}
+
+	for i := 0; i < len(lines); i++ {
+		for j := i + 1; j < len(lines); j++ {
+			a, b := lines[i], lines[j]
+			if a.Code == b.Code {
+				continue // Repeated codes are a units question for the MUE check
+			}
+
+			edit, found := n.ncciEdits.PairEdit(a.Code, b.Code, serviceDate)
+			if !found || edit.ModifierIndicator == ncci.ModifierNotApplicable {
+				continue
+			}
+
+			if edit.ModifierIndicator == ncci.ModifierAllowed &&
+				(hasBypassModifier(a.Modifiers) || hasBypassModifier(b.Modifiers)) {
+				continue
+			}
+
+			pair := edit.Column1 + "/" + edit.Column2
+			if seen[pair] {
+				continue
+			}
+			seen[pair] = true
+
+			// The column two code is the one that gets denied, so point there
+			column2 := b
+			if edit.Column2 == a.Code {
+				column2 = a
+			}
+
+			if edit.ModifierIndicator == ncci.ModifierNotAllowed {
+				violations = append(violations, violation(column2.Path, column2.Code,
+					"NCCI procedure-to-procedure edit: %s cannot be billed together (no modifier allowed)", pair)...)
+			} else {
+				violations = append(violations, violation(column2.Path, column2.Code,
+					"NCCI procedure-to-procedure edit: %s cannot be billed together without an NCCI-associated modifier (e.g. -59, -XS)",
+					pair)...)
+			}
+		}
+	}
+
+	return violations
+}
+
+func (n *Node) checkMedicallyUnlikelyEdits(ctx context.Context, data *Submission) []Violation {
+	if !n.ncciEdits.Loaded() {
+		return nil
+	}
+
+	lines, ok := procedureLines(data.ClaimData)
+	if !ok {
+		return nil
+	}
+
+	serviceDate := parseServiceDate(data.ClaimData)
+	violations := []Violation{}
+	unitsByCode := map[string]int{}
+	firstLine := map[string]string{}
+
+	for _, line := range lines {
+		unitsByCode[line.Code] += line.Units
+		if _, seen := firstLine[line.Code]; !seen {
+			firstLine[line.Code] = line.UnitsPath
+		}
+
+		edit, found := n.ncciEdits.MUE(line.Code, serviceDate)
+		if found && edit.PerLine() && line.Units > edit.MaxUnits {
+			violations = append(violations, violation(line.UnitsPath, line.Units,
+				"Medically unlikely edit: %s billed %d units on one line (MUE %d)",
+				line.Code, line.Units, edit.MaxUnits)...)
+		}
+	}
+
+	codes := make([]string, 0, len(unitsByCode))
+	for code := range unitsByCode {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		edit, found := n.ncciEdits.MUE(code, serviceDate)
+		if found && !edit.PerLine() && unitsByCode[code] > edit.MaxUnits {
+			violations = append(violations, violation(firstLine[code], unitsByCode[code],
+				"Medically unlikely edit: %s billed %d units on the date of service (MUE %d)",
+				code, unitsByCode[code], edit.MaxUnits)...)
+		}
+	}
+
+	return violations
+}
+
+func hasBypassModifier(modifiers []string) bool {
+	for _, modifier := range modifiers {
+		if ncci.IsBypassModifier(modifier) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,16 +2,28 @@ package verifier
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/big"
 	"regexp"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/rs/zerolog/log"
+	"github.com/saintparish4/apx/internal/attachment"
+	"github.com/saintparish4/apx/internal/codeset"
+	"github.com/saintparish4/apx/internal/coverage"
 	"github.com/saintparish4/apx/internal/domain"
 	"github.com/saintparish4/apx/internal/ethereum"
+	"github.com/saintparish4/apx/internal/feeschedule"
 	"github.com/saintparish4/apx/internal/ipfs"
+	"github.com/saintparish4/apx/internal/ncci"
+	"github.com/saintparish4/apx/internal/npi"
+	"github.com/saintparish4/apx/internal/payer"
+	"github.com/saintparish4/apx/internal/plugin"
+	"github.com/saintparish4/apx/internal/store"
 )
 
 // ValidationRule defines a single validation rule
@@ -19,38 +31,184 @@ type ValidationRule struct {
 	Name        string
 	Description string
 	Severity    string // error, warning, etc
-	Check       func(*domain.ClaimData) (bool, string)
+	ClaimType   string // profile the rule belongs to; empty applies to every claim
+	Check       func(context.Context, *Submission) []Violation
+}
+
+// Violation is one failure found by a rule. A rule passes when its check
+// returns no violations.
+type Violation struct {
+	Path    string      // JSON path into the claim, e.g. procedure_codes[2]
+	Value   interface{} // offending value, nil when the field is missing
+	Message string
+}
+
+// violation builds a single-violation result
+func violation(path string, value interface{}, format string, args ...interface{}) []Violation {
+	return []Violation{{Path: path, Value: value, Message: fmt.Sprintf(format, args...)}}
+}
+
+// fieldPath indexes a repeated claim field, e.g. fieldPath("procedure_codes", 2)
+func fieldPath(field string, index int) string {
+	return fmt.Sprintf("%s[%d]", field, index)
+}
+
+// Submission is a claim under validation together with the on-chain context
+// it was submitted in. ClaimID and Provider are zero for ad hoc validation.
+type Submission struct {
+	*domain.ClaimData
+	ClaimID  [32]byte
+	Provider common.Address
+	Amount   *big.Int // on-chain claim amount in 18-decimal units
+	DataHash [32]byte // on-chain data hash; zero for ad hoc validation
+
+	// AsOf pins the clock for date-relative rules when replaying a historical
+	// validation; zero means now
+	AsOf time.Time
+
+	ruleset   *Ruleset
+	payer     *payer.Profile       // nil when the claim names no known payer
+	documents []supportingDocument // fetched on first use
+
+	// indexed is set once the claim is in the duplicate index, with prior
+	// the claims indexed before it
+	indexed bool
+	prior   []*domain.ClaimFingerprint
+}
+
+// now returns the evaluation time of the submission
+func (sub *Submission) now() time.Time {
+	if sub.AsOf.IsZero() {
+		return time.Now()
+	}
+	return sub.AsOf
+}
+
+// ProviderDirectory resolves NPPES records and the NPIs providers registered
+// with off-chain
+type ProviderDirectory interface {
+	GetNPPESRecord(ctx context.Context, id string) (*npi.Record, error)
+	GetProviderNPI(ctx context.Context, wallet common.Address) (string, error)
 }
 
 // Node is the verification node service
 type Node struct {
 	ethService  *ethereum.Service
 	ipfsService *ipfs.Service
+	codeSets    *codeset.Registry
+	providers   ProviderDirectory
 	rules       []ValidationRule
+
+	ruleset       *Ruleset
+	rulesets      map[string]*Ruleset // historical rulesets by version
+	referenceData map[string][]string
+
+	feeSchedule *feeschedule.Schedule
+	ncciEdits   *ncci.Edits
+	coverage    *coverage.Policies
+	payers      *payer.Profiles
+	documents   *attachment.Requirements
+	plugins     *plugin.Set
+
+	claimIndex ClaimIndex
+	verdicts   VerdictStore
+	reviews    ReviewQueue
+
+	baselines  BaselineStore
+	baselineMu sync.Mutex
+}
+
+// Option configures optional reference data and behaviour of a Node
+type Option func(*Node)
+
+// WithCodeSets validates procedure and diagnosis codes against loaded code
+// sets instead of by format alone
+func WithCodeSets(registry *codeset.Registry) Option {
+	return func(n *Node) {
+		n.codeSets = registry
+	}
+}
+
+// WithProviderDirectory enables NPPES and registered-NPI checks
+func WithProviderDirectory(directory ProviderDirectory) Option {
+	return func(n *Node) {
+		n.providers = directory
+	}
+}
+
+// WithFeeSchedule flags procedures billed above a multiple of the allowed
+// amount; the locality and multiple come from the ruleset
+func WithFeeSchedule(schedule *feeschedule.Schedule) Option {
+	return func(n *Node) {
+		n.feeSchedule = schedule
+	}
+}
+
+// WithCoveragePolicies checks that submitted diagnoses support the medical
+// necessity of each procedure governed by a coverage policy
+func WithCoveragePolicies(policies *coverage.Policies) Option {
+	return func(n *Node) {
+		n.coverage = policies
+	}
 }
 
 // NewNode creates a new verification node
-func NewNode(ethService *ethereum.Service, ipfsService *ipfs.Service) *Node {
+func NewNode(ethService *ethereum.Service, ipfsService *ipfs.Service, opts ...Option) *Node {
 	node := &Node{
 		ethService:  ethService,
 		ipfsService: ipfsService,
+		ruleset:     DefaultRuleset(),
+		rulesets:    map[string]*Ruleset{},
+	}
+	for _, opt := range opts {
+		opt(node)
 	}
 	node.initializeRules()
+	node.referenceData = node.loadedReferenceData()
 	return node
 }
 
+// invalidClaimDataRule names the failure voted when claim data cannot be
+// decoded at all, in place of the failed rules
+const invalidClaimDataRule = "invalid_claim_data"
+
+var (
+	// Procedure codes are five characters, optionally followed by two-character
+	// modifiers (e.g. 99213-25, 27447-RT)
+	procedureCodeRegex = regexp.MustCompile(`^([0-9A-Z]{5})((?:-[0-9A-Z]{2})*)$`)
+
+	// CPT Category I codes are numeric; Category II and III end in F and T
+	cptRegex = regexp.MustCompile(`^\d{4}[0-9FT]$`)
+
+	// CDT codes are the HCPCS Level II D range, maintained by the ADA
+	cdtRegex = regexp.MustCompile(`^D\d{4}$`)
+
+	// HCPCS Level II codes are a letter followed by four digits (e.g. J1100)
+	hcpcsRegex = regexp.MustCompile(`^[A-V]\d{4}$`)
+
+	// ICD-10-CM: letter, digit, alphanumeric, then up to four alphanumeric
+	// characters after the (optional) decimal point (e.g. S72.001A)
+	icd10Regex = regexp.MustCompile(`^[A-Z]\d[0-9A-Z](\.?[0-9A-Z]{1,4})?$`)
+)
+
 // initializeRules sets up the validation rules
 func (n *Node) initializeRules() {
 	n.rules = []ValidationRule{
 		{
 			Name:        "valid_procedure_codes",
-			Description: "All procedure codes must be valid CPT codes",
+			Description: "All procedure codes must exist in the CPT or HCPCS code set on the service date",
 			Severity:    "error",
 			Check:       n.checkProcedureCodes,
 		},
+		{
+			Name:        "valid_service_lines",
+			Description: "Service lines must have valid modifiers, units and diagnosis pointers, and charges that sum to the billed amount",
+			Severity:    "error",
+			Check:       n.checkServiceLines,
+		},
 		{
 			Name:        "valid_diagnosis_codes",
-			Description: "All diagnosis codes must be valid ICD-10 format",
+			Description: "All diagnosis codes must exist in the ICD-10-CM code set on the service date",
 			Severity:    "error",
 			Check:       n.checkDiagnosisCodes,
 		},
@@ -60,37 +218,135 @@ func (n *Node) initializeRules() {
 			Severity:    "error",
 			Check:       n.checkAmount,
 		},
+		{
+			Name:        "amount_matches_chain",
+			Description: "Billed amount must equal the amount submitted on-chain",
+			Severity:    "error",
+			Check:       n.checkAmountMatchesChain,
+		},
+		{
+			Name:        "data_hash_matches_chain",
+			Description: "Salted claim data must hash to the data hash committed on-chain",
+			Severity:    "error",
+			Check:       n.checkDataHashMatchesChain,
+		},
+		{
+			Name:        "financial_consistency",
+			Description: "Allowed, copay and deductible amounts must be non-negative and consistent with the billed amount",
+			Severity:    "error",
+			Check:       n.checkFinancialConsistency,
+		},
 		{
 			Name:        "valid_service_date",
-			Description: "Service date must be in the past and not too old",
+			Description: "Service date must be in the past and within the timely filing limit",
 			Severity:    "error",
 			Check:       n.checkServiceDate,
 		},
 		{
 			Name:        "valid_npi",
-			Description: "Provider NPI must be valid format",
+			Description: "Provider NPI must be 10 digits with a valid check digit",
 			Severity:    "error",
 			Check:       n.checkNPI,
 		},
+		{
+			Name:        "active_npi",
+			Description: "Provider NPI must not be deactivated in NPPES",
+			Severity:    "error",
+			Check:       n.checkNPIActive,
+		},
+		{
+			Name:        "npi_matches_provider",
+			Description: "Provider NPI must match the submitting provider's registered NPI",
+			Severity:    "error",
+			Check:       n.checkNPIMatchesProvider,
+		},
+		{
+			Name:        "valid_claim_type",
+			Description: "Claim type must select a known validation profile",
+			Severity:    "error",
+			Check:       n.checkClaimType,
+		},
+		{
+			Name:        "known_payer",
+			Description: "Payer ID must name a loaded payer profile",
+			Severity:    "error",
+			Check:       n.checkKnownPayer,
+		},
+		{
+			Name:        "payer_claim_type",
+			Description: "Payer must accept the claim type",
+			Severity:    "error",
+			Check:       n.checkPayerClaimType,
+		},
+		{
+			Name:        "payer_covered_procedures",
+			Description: "Payer must cover every billed procedure",
+			Severity:    "error",
+			Check:       n.checkPayerCoveredProcedures,
+		},
 		{
 			Name:        "has_required_fields",
 			Description: "All required fields must be present",
 			Severity:    "error",
 			Check:       n.checkRequiredFields,
 		},
+		{
+			Name:        "ncci_procedure_pairs",
+			Description: "Procedure pairs must not be bundled or mutually exclusive under NCCI PTP edits",
+			Severity:    "error",
+			Check:       n.checkNCCIProcedurePairs,
+		},
+		{
+			Name:        "medically_unlikely_units",
+			Description: "Units of service must not exceed NCCI medically unlikely edits",
+			Severity:    "error",
+			Check:       n.checkMedicallyUnlikelyEdits,
+		},
+		{
+			Name:        "no_duplicate_claim",
+			Description: "Claim must not duplicate a previously submitted claim",
+			Severity:    "error",
+			Check:       n.checkDuplicateClaim,
+		},
+		{
+			Name:        "possible_duplicate_claim",
+			Description: "Claim should not overlap another claim for the same patient and service date",
+			Severity:    "warning",
+			Check:       n.checkPossibleDuplicate,
+		},
 		{
 			Name:        "reasonable_amount_for_procedure",
-			Description: "Amount should be reasonable for the procedures",
+			Description: "Billed amount per procedure should be within a multiple of the fee schedule",
 			Severity:    "warning",
 			Check:       n.checkAmountReasonableness,
 		},
+		{
+			Name:        "readable_documents",
+			Description: "Supporting documents must be retrievable, decrypt and be an allowed document type",
+			Severity:    "warning",
+			Check:       n.checkSupportingDocuments,
+		},
+		{
+			Name:        "required_documents",
+			Description: "Procedures must be backed by the supporting documents their requirements declare",
+			Severity:    "error",
+			Check:       n.checkDocumentRequirements("error"),
+		},
+		{
+			Name:        "recommended_documents",
+			Description: "Procedures should be backed by the supporting documents their requirements recommend",
+			Severity:    "warning",
+			Check:       n.checkDocumentRequirements("warning"),
+		},
 		{
 			Name:        "diagnosis_procedure_match",
-			Description: "Diagnosis and procedure codes should be compatible",
+			Description: "Each procedure governed by a coverage policy should have a supporting diagnosis",
 			Severity:    "warning",
 			Check:       n.checkDiagnosisProcedureMatch,
 		},
 	}
+	n.rules = append(n.rules, n.profileRules()...)
+	n.rules = append(n.rules, n.pluginRules()...)
 }
 
 // Start starts the verification node to listen for claim events
@@ -102,6 +358,10 @@ func (n *Node) Start(ctx context.Context) error {
 
 	log.Info().Msg("Verification node started, listening for claims...")
 
+	if n.reviews != nil {
+		go n.reviewLoop(ctx)
+	}
+
 	go func() {
 		for event := range events {
 			if event.Type == "ClaimSubmitted" {
@@ -124,168 +384,495 @@ func (n *Node) processClaim(ctx context.Context, event *ethereum.ClaimEvent) {
 
 	// Retrieve claim data from IPFS
 	claimData, err := n.ipfsService.RetrieveClaimData(ctx, event.IPFSCID)
+	if errors.Is(err, ipfs.ErrInvalidClaimData) {
+		// Malformed data can never pass validation
+		logger.Warn().Err(err).Msg("Claim data is malformed")
+		n.vote(ctx, event.ClaimID, false, "Rejected: "+invalidClaimDataRule+"; ruleset "+n.ruleset.Version)
+		return
+	}
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to retrieve claim data from IPFS")
-		// TODO: Submit rejection transaction
 		return
 	}
 
 	// Validate the claim
-	result := n.ValidateClaim(claimData)
+	sub := &Submission{
+		ClaimData: claimData,
+		ClaimID:   event.ClaimID,
+		Provider:  event.Provider,
+		Amount:    event.Amount,
+		DataHash:  event.DataHash,
+	}
+
+	// Index the claim so resubmissions are caught as duplicates, including
+	// those processed alongside it, then validate it against the claims
+	// indexed before it
+	n.recordFingerprint(ctx, sub)
+	result := n.ValidateSubmission(ctx, sub)
+
+	// Keep the verdict for audits and fold valid claims into the provider's
+	// billing baseline
+	n.recordVerdict(ctx, sub, event.IPFSCID, result)
+	n.updateBaseline(ctx, sub, result)
 
 	logger.Info().
 		Bool("approved", result.Approved).
 		Float64("score", result.Score).
+		Str("risk_level", result.RiskLevel).
 		Strs("reasons", result.Reasons).
 		Msg("Validation completed")
+
+	// Vote, or park the claim for a human reviewer
+	n.routeClaim(ctx, sub, event, result)
 }
 
 // ValdiateClaim validates claim data against all rules
 func (n *Node) ValidateClaim(claimData *domain.ClaimData) *domain.ValidationResult {
+	return n.ValidateSubmission(context.Background(), &Submission{ClaimData: claimData})
+}
+
+// ValidateSubmission validates a claim along with its submission context
+// under the active ruleset
+func (n *Node) ValidateSubmission(ctx context.Context, sub *Submission) *domain.ValidationResult {
+	if sub.ruleset == nil {
+		sub.ruleset = n.ruleset
+	}
+	rs := sub.ruleset
+	if sub.payer == nil {
+		sub.payer, _ = n.payers.Lookup(sub.PayerID)
+	}
+	errorPenalty, warningPenalty := scoreWeights(rs, sub.payer)
+
 	result := &domain.ValidationResult{
 		Valid:    true,
 		Approved: true,
 		Score:    100.0,
 		Reasons:  []string{},
 		Warnings: []string{},
+		Findings: []domain.Finding{},
 	}
 
 	errorCount := 0
 	warningCount := 0
 
 	for _, rule := range n.rules {
-		passed, message := rule.Check(claimData)
-		if !passed {
-			if rule.Severity == "error" {
-				errorCount++
-				result.Reasons = append(result.Reasons, fmt.Sprintf("%s: %s", rule.Name, message))
-			} else {
-				warningCount++
-				result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %s", rule.Name, message))
-			}
+		if rs.Disabled(rule.Name) || rule.ClaimType != "" && rule.ClaimType != sub.ClaimType {
+			continue
+		}
+
+		violations := rule.Check(ctx, sub)
+		if len(violations) == 0 {
+			continue
 		}
+
+		// A failed rule costs the same however many violations it found;
+		// the penalty is shared between its findings
+		penalty := warningPenalty
+		if rule.Severity == "error" {
+			errorCount++
+			penalty = errorPenalty
+		} else {
+			warningCount++
+		}
+
+		messages := make([]string, len(violations))
+		for i, v := range violations {
+			messages[i] = v.Message
+			result.Findings = append(result.Findings, domain.Finding{
+				Rule:        rule.Name,
+				Severity:    rule.Severity,
+				Path:        v.Path,
+				Value:       v.Value,
+				Message:     v.Message,
+				ScoreImpact: penalty / float64(len(violations)),
+			})
+		}
+
+		legacy := fmt.Sprintf("%s: %s", rule.Name, strings.Join(messages, "; "))
+		if rule.Severity == "error" {
+			result.Reasons = append(result.Reasons, legacy)
+		} else {
+			result.Warnings = append(result.Warnings, legacy)
+		}
+	}
+
+	// Provider-level anomalies lower the score without failing the claim
+	factors := n.anomalyFactors(ctx, sub)
+	for _, factor := range factors {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("provider_anomaly: %s", factor.Detail))
+		result.Findings = append(result.Findings, anomalyFinding(sub, factor, rs.Anomaly.PenaltyPerAlert))
 	}
+	anomalyPenalty := float64(len(factors)) * rs.Anomaly.PenaltyPerAlert
 
 	// Calculate score (errors have more impact than warnings)
-	result.Score = 100.0 - float64(errorCount*15) - float64(warningCount*5)
+	result.Score = 100.0 - float64(errorCount)*errorPenalty - float64(warningCount)*warningPenalty - anomalyPenalty
 	if result.Score < 0 {
 		result.Score = 0
 	}
 
 	// Determine validity and approval
 	result.Valid = errorCount == 0
-	result.Approved = errorCount == 0 && result.Score >= 60
+	result.Approved = errorCount == 0 && result.Score >= rs.ApprovalScore
 
 	// Determine risk level
 	switch {
-	case errorCount > 0 || result.Score < 50:
+	case errorCount > 0 || result.Score < rs.HighRiskScore:
 		result.RiskLevel = "high"
-	case warningCount > 1 || result.Score < 80:
+	case warningCount > 1 || len(factors) > 0 || result.Score < rs.MediumRiskScore:
 		result.RiskLevel = "medium"
 	default:
 		result.RiskLevel = "low"
 	}
 
+	result.Ruleset = n.rulesetInfo(rs, sub.now().UTC())
+
 	return result
 }
 
 // Individual validation checks
 
-func (n *Node) checkProcedureCodes(data *domain.ClaimData) (bool, string) {
-	if len(data.ProcedureCodes) == 0 {
-		return false, "No procedure codes provided"
+func (n *Node) checkProcedureCodes(ctx context.Context, data *Submission) []Violation {
+	procedures := submittedProcedures(data.ClaimData)
+	if len(procedures) == 0 {
+		if len(data.ServiceLines) > 0 {
+			return nil // Revenue-code-only lines; the institutional profile checks them
+		}
+		return violation("procedure_codes", nil, "No procedure codes provided")
 	}
 
-	// CPT codes are 5 digits, optionally followed by modifiers
-	cptRegex := regexp.MustCompile(`^\d{5}(-\d{2})?$`)
+	serviceDate := parseServiceDate(data.ClaimData)
+	violations := []Violation{}
+
+	for _, procedure := range procedures {
+		path, code := procedure.Path, procedure.Code
+
+		base, _, ok := splitProcedureCode(code)
+		if !ok {
+			violations = append(violations, violation(path, code, "Invalid procedure code format: %s", code)...)
+			continue
+		}
+
+		system := procedureSystem(base)
+		if system == "" {
+			violations = append(violations, violation(path, code, "Invalid CPT/HCPCS code: %s", code)...)
+			continue
+		}
 
-	for _, code := range data.ProcedureCodes {
-		if !cptRegex.MatchString(code) {
-			return false, fmt.Sprintf("Invalid CPT code: %s", code)
+		if ok, message := n.lookupCode(system, base, serviceDate); !ok {
+			violations = append(violations, Violation{Path: path, Value: code, Message: message})
 		}
 	}
 
-	return true, ""
+	return violations
 }
 
-func (n *Node) checkDiagnosisCodes(data *domain.ClaimData) (bool, string) {
+func (n *Node) checkDiagnosisCodes(ctx context.Context, data *Submission) []Violation {
 	if len(data.DiagnosisCodes) == 0 {
-		return false, "No diagnosis codes provided"
+		return violation("diagnosis_codes", nil, "No diagnosis codes provided")
 	}
 
-	// ICD-10 format: Letter + 2 digits + optional decimal + up to 4 more chars
-	icd10Regex := regexp.MustCompile(`^[A-Z]\d{2}(\.\d{1,4})?$`)
+	serviceDate := parseServiceDate(data.ClaimData)
+	violations := []Violation{}
+
+	for i, code := range data.DiagnosisCodes {
+		path := fieldPath("diagnosis_codes", i)
 
-	for _, code := range data.DiagnosisCodes {
 		if !icd10Regex.MatchString(code) {
-			return false, fmt.Sprintf("Invalid ICD-10 code format: %s", code)
+			violations = append(violations, violation(path, code, "Invalid ICD-10 code format: %s", code)...)
+			continue
+		}
+
+		if ok, message := n.lookupCode(codeset.ICD10CM, code, serviceDate); !ok {
+			violations = append(violations, Violation{Path: path, Value: code, Message: message})
 		}
 	}
 
-	return true, ""
+	return violations
+}
+
+// lookupCode checks a code against the loaded code set. When no code set is
+// loaded for the system the format check is all we can do.
+func (n *Node) lookupCode(system codeset.System, code string, serviceDate time.Time) (bool, string) {
+	if !n.codeSets.Has(system) {
+		return true, ""
+	}
+
+	_, err := n.codeSets.Lookup(system, code, serviceDate)
+	switch {
+	case err == nil:
+		return true, ""
+	case errors.Is(err, codeset.ErrNotEffective):
+		return false, fmt.Sprintf("%s code %s not effective on %s",
+			systemLabel(system), code, serviceDate.Format("2006-01-02"))
+	default:
+		return false, fmt.Sprintf("Unknown %s code: %s", systemLabel(system), code)
+	}
+}
+
+// splitProcedureCode separates a procedure code from its modifiers
+func splitProcedureCode(code string) (string, []string, bool) {
+	match := procedureCodeRegex.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(code)))
+	if match == nil {
+		return "", nil, false
+	}
+
+	var modifiers []string
+	if match[2] != "" {
+		modifiers = strings.Split(strings.TrimPrefix(match[2], "-"), "-")
+	}
+
+	return match[1], modifiers, true
+}
+
+// procedureSystem reports which code system a procedure code belongs to
+func procedureSystem(code string) codeset.System {
+	switch {
+	case cptRegex.MatchString(code):
+		return codeset.CPT
+	case cdtRegex.MatchString(code):
+		return codeset.CDT
+	case hcpcsRegex.MatchString(code):
+		return codeset.HCPCS
+	default:
+		return ""
+	}
 }
 
-func (n *Node) checkAmount(data *domain.ClaimData) (bool, string) {
-	amount, err := strconv.ParseFloat(data.BilledAmount, 64)
+func systemLabel(system codeset.System) string {
+	switch system {
+	case codeset.CPT:
+		return "CPT"
+	case codeset.HCPCS:
+		return "HCPCS"
+	case codeset.CDT:
+		return "CDT"
+	case codeset.ICD10CM:
+		return "ICD-10-CM"
+	case codeset.POS:
+		return "place of service"
+	default:
+		return string(system)
+	}
+}
+
+// parseServiceDate returns the claim's service date, or the zero time if it
+// is missing or malformed (checkServiceDate reports that separately)
+func parseServiceDate(data *domain.ClaimData) time.Time {
+	serviceDate, err := time.Parse("2006-01-02", data.ServiceDate)
 	if err != nil {
-		return false, "Invalid amount format"
+		return time.Time{}
 	}
+	return serviceDate
+}
+
+func (n *Node) checkAmount(ctx context.Context, data *Submission) []Violation {
+	if data.BilledAmount == nil {
+		return nil // Reported by checkRequiredFields
+	}
+	amount := *data.BilledAmount
 
 	if amount <= 0 {
-		return false, "Amount must be positive"
+		return violation("billed_amount", amount, "Amount must be positive")
+	}
+
+	if amount > data.ruleset.MaxClaimAmount {
+		return violation("billed_amount", amount, "Amount exceeds maximum allowed of $%s", data.ruleset.MaxClaimAmount)
+	}
+
+	return nil
+}
+
+// checkFinancialConsistency reports one violation per broken relation
+// between the claim's amounts. The billed amount's sign is checked by
+// checkAmount, and absent amounts take no part.
+func (n *Node) checkFinancialConsistency(ctx context.Context, data *Submission) []Violation {
+	violations := []Violation{}
+
+	for _, amount := range []struct {
+		field, label string
+		value        *domain.Money
+	}{
+		{"allowed_amount", "Allowed amount", data.AllowedAmount},
+		{"copay_amount", "Copay", data.CopayAmount},
+		{"deductible_amount", "Deductible", data.DeductibleAmount},
+	} {
+		if amount.value != nil && *amount.value < 0 {
+			violations = append(violations, violation(amount.field, *amount.value,
+				"%s $%s must not be negative", amount.label, *amount.value)...)
+		}
+	}
+
+	if data.AllowedAmount == nil {
+		return violations
+	}
+	allowed := *data.AllowedAmount
+
+	if data.BilledAmount != nil && allowed > *data.BilledAmount {
+		violations = append(violations, violation("allowed_amount", allowed,
+			"Allowed amount $%s exceeds billed amount $%s", allowed, *data.BilledAmount)...)
+	}
+
+	if data.CopayAmount != nil || data.DeductibleAmount != nil {
+		var copay, deductible domain.Money
+		path := "deductible_amount"
+		if data.CopayAmount != nil {
+			copay, path = *data.CopayAmount, "copay_amount"
+		}
+		if data.DeductibleAmount != nil {
+			deductible = *data.DeductibleAmount
+		}
+		if copay+deductible > allowed {
+			violations = append(violations, violation(path, copay+deductible,
+				"Copay $%s plus deductible $%s exceeds allowed amount $%s", copay, deductible, allowed)...)
+		}
+	}
+
+	return violations
+}
+
+// checkAmountMatchesChain compares the billed amount with the amount the
+// provider committed on-chain, which the contract holds in 18-decimal units
+func (n *Node) checkAmountMatchesChain(ctx context.Context, data *Submission) []Violation {
+	if data.Amount == nil || data.BilledAmount == nil {
+		return nil // Ad hoc validation, or reported by checkRequiredFields
 	}
 
-	// Max reasonable claim amount ($1M)
-	if amount > 1000000 {
-		return false, "Amount exceeds maximum allowed"
+	onChain, err := domain.MoneyFromWei(data.Amount)
+	if err != nil {
+		return violation("billed_amount", *data.BilledAmount, "On-chain amount is not a whole number of cents: %s", data.Amount)
+	}
+	if onChain != *data.BilledAmount {
+		return violation("billed_amount", *data.BilledAmount, "Billed amount $%s does not match on-chain amount $%s", *data.BilledAmount, onChain)
 	}
 
-	return true, ""
+	return nil
 }
 
-func (n *Node) checkServiceDate(data *domain.ClaimData) (bool, string) {
+// checkDataHashMatchesChain recomputes the canonical data hash of the claim
+// data retrieved from IPFS, salt included, and compares it with the on-chain
+// commitment. Unsalted data is rejected: its hash could be confirmed by
+// anyone able to guess the claim.
+func (n *Node) checkDataHashMatchesChain(ctx context.Context, data *Submission) []Violation {
+	if data.DataHash == ([32]byte{}) {
+		return nil // Ad hoc validation
+	}
+	if err := domain.CheckSalt(data.Salt); err != nil {
+		return violation("salt", data.Salt, "Claim data hash cannot be verified: %v", err)
+	}
+
+	dataHash, _, err := ethereum.ClaimDataHash(data.ClaimData)
+	if err != nil {
+		return violation("", nil, "Claim data cannot be canonicalized: %v", err)
+	}
+	if dataHash != data.DataHash {
+		return violation("", nil, "Claim data hash 0x%x does not match on-chain data hash 0x%x", dataHash, data.DataHash)
+	}
+
+	return nil
+}
+
+func (n *Node) checkServiceDate(ctx context.Context, data *Submission) []Violation {
 	if data.ServiceDate == "" {
-		return false, "Service date is required"
+		return violation("service_date", nil, "Service date is required")
 	}
 
 	serviceDate, err := time.Parse("2006-01-02", data.ServiceDate)
 	if err != nil {
-		return false, "Invalid service date format (YYYY-MM-DD)"
+		return violation("service_date", data.ServiceDate, "Invalid service date format (YYYY-MM-DD)")
 	}
 
-	now := time.Now()
+	now := data.now()
 
 	// Service date cannot be in the future
 	if serviceDate.After(now) {
-		return false, "Service date cannot be in the future"
+		return violation("service_date", data.ServiceDate, "Service date cannot be in the future")
+	}
+
+	// Service date cannot be older than the payer's timely filing limit,
+	// or the ruleset's when the claim names no payer
+	if data.payer != nil {
+		limit := data.payer.FilingLimitDays(data.OriginalClaimID != "")
+		if serviceDate.Before(now.AddDate(0, 0, -limit)) {
+			return violation("service_date", data.ServiceDate, "Service date is past the %d-day timely filing limit of payer %s",
+				limit, data.payer.ID)
+		}
+		return nil
 	}
 
-	// Service date cannot be more than 1 year old
-	oneYearAgo := now.AddDate(-1, 0, 0)
-	if serviceDate.Before(oneYearAgo) {
-		return false, "Service date cannot be more than 1 year old"
+	oldest := now.AddDate(0, 0, -data.ruleset.MaxServiceAgeDays)
+	if serviceDate.Before(oldest) {
+		return violation("service_date", data.ServiceDate, "Service date cannot be more than %d days old",
+			data.ruleset.MaxServiceAgeDays)
 	}
 
-	return true, ""
+	return nil
 }
 
-func (n *Node) checkNPI(data *domain.ClaimData) (bool, string) {
+func (n *Node) checkNPI(ctx context.Context, data *Submission) []Violation {
 	if data.ProviderNPI == "" {
-		return false, "Provider NPI is required"
+		return violation("provider_npi", nil, "Provider NPI is required")
 	}
 
 	// NPI is exactly 10 digits
 	npiRegex := regexp.MustCompile(`^\d{10}$`)
 	if !npiRegex.MatchString(data.ProviderNPI) {
-		return false, "Invalid NPI format (must be 10 digits)"
+		return violation("provider_npi", data.ProviderNPI, "Invalid NPI format (must be 10 digits)")
+	}
+
+	// Check digit is Luhn over the NPI prefixed with 80840
+	if !npi.Valid(data.ProviderNPI) {
+		return violation("provider_npi", data.ProviderNPI, "Invalid NPI check digit: %s", data.ProviderNPI)
+	}
+
+	return nil
+}
+
+func (n *Node) checkNPIActive(ctx context.Context, data *Submission) []Violation {
+	if n.providers == nil || !npi.Valid(data.ProviderNPI) {
+		return nil // Format problems are reported by checkNPI
+	}
+
+	record, err := n.providers.GetNPPESRecord(ctx, data.ProviderNPI)
+	if errors.Is(err, store.ErrNotFound) {
+		// The local registry may hold only a subset of NPPES
+		return nil
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("npi", data.ProviderNPI).Msg("NPPES lookup failed")
+		return nil
 	}
 
-	// TODO: Validate NPI checksum (Luhn algorithm variant)
+	if record.DeactivatedOn(parseServiceDate(data.ClaimData)) {
+		return violation("provider_npi", data.ProviderNPI, "NPI %s was deactivated on %s",
+			data.ProviderNPI, record.DeactivationDate.Format("2006-01-02"))
+	}
 
-	return true, ""
+	return nil
 }
 
-func (n *Node) checkRequiredFields(data *domain.ClaimData) (bool, string) {
+func (n *Node) checkNPIMatchesProvider(ctx context.Context, data *Submission) []Violation {
+	if n.providers == nil || data.Provider == (common.Address{}) || data.ProviderNPI == "" {
+		return nil
+	}
+
+	registered, err := n.providers.GetProviderNPI(ctx, data.Provider)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil // Provider has no NPI on file to compare against
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("provider", data.Provider.Hex()).Msg("Provider NPI lookup failed")
+		return nil
+	}
+
+	if registered != data.ProviderNPI {
+		return violation("provider_npi", data.ProviderNPI, "NPI %s does not match NPI %s registered for provider %s",
+			data.ProviderNPI, registered, data.Provider.Hex())
+	}
+
+	return nil
+}
+
+func (n *Node) checkRequiredFields(ctx context.Context, data *Submission) []Violation {
 	missing := []string{}
 
 	if data.PatientID == "" {
@@ -297,61 +884,130 @@ func (n *Node) checkRequiredFields(data *domain.ClaimData) (bool, string) {
 	if data.ServiceDate == "" {
 		missing = append(missing, "service_date")
 	}
-	if len(data.ProcedureCodes) == 0 {
+	if len(data.ProcedureCodes) == 0 && len(data.ServiceLines) == 0 {
 		missing = append(missing, "procedure_codes")
 	}
 	if len(data.DiagnosisCodes) == 0 {
 		missing = append(missing, "diagnosis_codes")
 	}
-	if data.BilledAmount == "" {
+	if data.BilledAmount == nil {
 		missing = append(missing, "billed_amount")
 	}
 	if data.ClaimType == "" {
 		missing = append(missing, "claim_type")
 	}
 
-	if len(missing) > 0 {
-		return false, fmt.Sprintf("Missing required fields: %s", strings.Join(missing, ", "))
-	}
+	return missingFields(missing...)
+}
 
-	return true, ""
+// missingFields reports each named field as missing
+func missingFields(fields ...string) []Violation {
+	violations := make([]Violation, len(fields))
+	for i, field := range fields {
+		violations[i] = Violation{Path: field, Message: fmt.Sprintf("Missing required field: %s", field)}
+	}
+	return violations
 }
 
-func (n *Node) checkAmountReasonableness(data *domain.ClaimData) (bool, string) {
-	amount, err := strconv.ParseFloat(data.BilledAmount, 64)
-	if err != nil {
-		return true, "" // Already checked in checkAmount
+func (n *Node) checkAmountReasonableness(ctx context.Context, data *Submission) []Violation {
+	if !n.feeSchedule.Loaded() {
+		return nil // Nothing to compare against
 	}
 
-	// Guard against division by zero when no procedure codes provided
-	if len(data.ProcedureCodes) == 0 {
-		return true, "" // Already checked in checkProcedureCodes
+	lines, ok := procedureLines(data.ClaimData)
+	if !ok {
+		return nil // Already checked in checkAmount and checkProcedureCodes
 	}
 
-	// Simple heuristic: flag if amount is unusually high for number of procedures
-	avgPerProcedure := amount / float64(len(data.ProcedureCodes))
+	serviceDate := parseServiceDate(data.ClaimData)
+	violations := []Violation{}
+
+	for _, line := range lines {
+		if line.Charge == 0 {
+			continue
+		}
 
-	// Flag if average is over $10k per procedure (very rough heuristic)
-	if avgPerProcedure > 10000 {
-		return false, fmt.Sprintf("Amount seems high: $%.2f per procedure", avgPerProcedure)
+		entry, found := n.feeSchedule.Lookup(line.Code, line.Modifiers, data.ruleset.FeeLocality, serviceDate)
+		if !found || entry.AllowedAmount == 0 {
+			continue // Codes without a schedule amount can't be judged
+		}
+
+		// Schedule amounts are per unit; the line charge covers every unit
+		units := line.Units
+		if units <= 0 {
+			units = 1
+		}
+		allowed := entry.AllowedAmount * float64(units)
+
+		ratio := line.Charge.Float64() / allowed
+		if ratio > data.ruleset.FeeMaxMultiple {
+			violations = append(violations, violation(line.Path, line.Code,
+				"%s x %d billed $%s vs $%.2f allowed (%.1fx, above %.1fx fee schedule)",
+				line.Code, units, line.Charge, allowed, ratio, data.ruleset.FeeMaxMultiple)...)
+		}
 	}
 
-	return true, ""
+	return violations
 }
 
-func (n *Node) checkDiagnosisProcedureMatch(data *domain.ClaimData) (bool, string) {
-	// This would ideally use a medical coding database to validate
-	// that the procedures are appropriate for the diagnoses
-	// For MVP, I will just check that we have both
+func (n *Node) checkDiagnosisProcedureMatch(ctx context.Context, data *Submission) []Violation {
+	if len(data.DiagnosisCodes) == 0 || len(data.ProcedureCodes) == 0 && len(data.ServiceLines) == 0 {
+		return violation("diagnosis_codes", nil, "Both diagnosis and procedure codes required")
+	}
 
-	if len(data.DiagnosisCodes) == 0 || len(data.ProcedureCodes) == 0 {
-		return false, "Both diagnosis and procedure codes required"
+	if !n.coverage.Loaded() {
+		return nil // No coverage policies to check medical necessity against
 	}
 
-	// TODO: Implement actual medical coding validation
-	// This would involve looking up CPT/ICD-10 compatibility matrices
+	serviceDate := parseServiceDate(data.ClaimData)
+	violations := []Violation{}
+
+	// Malformed codes are left out; checkProcedureCodes reports them
+	lines, _ := procedureLines(data.ClaimData)
+	for _, line := range lines {
+		policies := n.coverage.ForProcedure(line.Code, serviceDate)
+		if len(policies) == 0 {
+			continue // No policy governs this procedure
+		}
 
-	return true, ""
+		// Service lines name the diagnoses they treat; flat claims are
+		// judged against all of them
+		diagnoses := data.DiagnosisCodes
+		if line.Diagnoses != nil {
+			diagnoses = line.Diagnoses
+		}
+
+		if !diagnosisSupports(policies, diagnoses) {
+			ids := make([]string, len(policies))
+			for i, policy := range policies {
+				ids[i] = policy.ID
+			}
+			violations = append(violations, violation(line.Path, line.Code,
+				"No %s diagnosis supports medical necessity for %s (%s)", diagnosisScope(line), line.Code, strings.Join(ids, ", "))...)
+		}
+	}
+
+	return violations
+}
+
+// diagnosisScope describes the diagnoses a line was judged against
+func diagnosisScope(line procedureLine) string {
+	if line.Diagnoses != nil {
+		return "pointed-to"
+	}
+	return "submitted"
+}
+
+// diagnosisSupports reports whether any diagnosis satisfies any of the policies
+func diagnosisSupports(policies []*coverage.Policy, diagnoses []string) bool {
+	for _, policy := range policies {
+		for _, diagnosis := range diagnoses {
+			if policy.SupportsDiagnosis(diagnosis) {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // ManualValidate allows manual validation without blockchain events
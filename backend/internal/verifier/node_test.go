@@ -0,0 +1,55 @@
+package verifier
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/saintparish4/apx/internal/domain"
+	"github.com/saintparish4/apx/internal/feeschedule"
+)
+
+func TestAmountReasonablenessPerUnit(t *testing.T) {
+	schedule, err := feeschedule.Load("../../data/feeschedules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := NewNode(nil, nil, WithFeeSchedule(schedule))
+
+	tests := []struct {
+		name    string
+		line    domain.ServiceLine
+		flagged string // expected message fragment; empty when within the schedule
+	}{
+		// J1100 is $0.12 per unit
+		{"units at schedule", domain.ServiceLine{ProcedureCode: "J1100", Units: 10, Charge: 120}, ""},
+		{"units within multiple", domain.ServiceLine{ProcedureCode: "J1100", Units: 10, Charge: 300}, ""},
+		{"units above multiple", domain.ServiceLine{ProcedureCode: "J1100", Units: 10, Charge: 1200}, "J1100 x 10 billed $12.00 vs $1.20 allowed (10.0x"},
+		{"single unit above multiple", domain.ServiceLine{ProcedureCode: "99213", Units: 1, Charge: 50000}, "99213 x 1 billed $500.00 vs $92.00 allowed"},
+		{"zero units priced as one", domain.ServiceLine{ProcedureCode: "99213", Units: 0, Charge: 50000}, "99213 x 1 billed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := &Submission{
+				ClaimData: &domain.ClaimData{
+					ServiceDate:    "2026-10-01",
+					DiagnosisCodes: []string{"E11.9"},
+					ServiceLines:   []domain.ServiceLine{tt.line},
+				},
+				ruleset: DefaultRuleset(),
+			}
+			violations := node.checkAmountReasonableness(context.Background(), sub)
+
+			if tt.flagged == "" {
+				if len(violations) != 0 {
+					t.Fatalf("got %v, want no violations", violations)
+				}
+				return
+			}
+			if len(violations) != 1 || !strings.Contains(violations[0].Message, tt.flagged) {
+				t.Fatalf("got %v, want one violation containing %q", violations, tt.flagged)
+			}
+		})
+	}
+}
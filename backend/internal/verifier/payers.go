@@ -0,0 +1,74 @@
+package verifier
+
+import (
+	"context"
+
+	"github.com/saintparish4/apx/internal/payer"
+)
+
+// WithPayerProfiles selects a payer profile for each claim by its payer ID.
+// Claims that name no payer are held to the ruleset alone.
+func WithPayerProfiles(payers *payer.Profiles) Option {
+	return func(n *Node) {
+		n.payers = payers
+	}
+}
+
+// scoreWeights returns the error and warning penalties for a claim: the
+// ruleset's, unless the payer weights them differently
+func scoreWeights(rs *Ruleset, p *payer.Profile) (errorPenalty, warningPenalty float64) {
+	errorPenalty, warningPenalty = rs.ErrorPenalty, rs.WarningPenalty
+	if p == nil {
+		return errorPenalty, warningPenalty
+	}
+	if p.ScoreWeights.ErrorPenalty != nil {
+		errorPenalty = *p.ScoreWeights.ErrorPenalty
+	}
+	if p.ScoreWeights.WarningPenalty != nil {
+		warningPenalty = *p.ScoreWeights.WarningPenalty
+	}
+	return errorPenalty, warningPenalty
+}
+
+func (n *Node) checkKnownPayer(ctx context.Context, data *Submission) []Violation {
+	if data.PayerID == "" || !n.payers.Loaded() {
+		return nil // No payer to select, or no profiles to select from
+	}
+
+	if data.payer == nil {
+		return violation("payer_id", data.PayerID, "Unknown payer ID: %s", data.PayerID)
+	}
+
+	return nil
+}
+
+func (n *Node) checkPayerClaimType(ctx context.Context, data *Submission) []Violation {
+	if data.payer == nil || data.ClaimType == "" {
+		return nil
+	}
+
+	if !data.payer.AcceptsClaimType(data.ClaimType) {
+		return violation("claim_type", data.ClaimType, "Payer %s does not accept %s claims", data.payer.ID, data.ClaimType)
+	}
+
+	return nil
+}
+
+func (n *Node) checkPayerCoveredProcedures(ctx context.Context, data *Submission) []Violation {
+	if data.payer == nil {
+		return nil
+	}
+
+	violations := []Violation{}
+
+	// Malformed codes are left out; checkProcedureCodes reports them
+	lines, _ := procedureLines(data.ClaimData)
+	for _, line := range lines {
+		if !data.payer.CoversProcedure(line.Code) {
+			violations = append(violations, violation(line.Path, line.Code,
+				"%s is not covered by payer %s", line.Code, data.payer.ID)...)
+		}
+	}
+
+	return violations
+}
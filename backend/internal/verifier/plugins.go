@@ -0,0 +1,57 @@
+package verifier
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/saintparish4/apx/internal/plugin"
+)
+
+// WithPlugins adds the loaded WebAssembly plugin rules after the built-in
+// rules. Each runs as plugin.<name> and can be disabled by a ruleset like
+// any other rule.
+func WithPlugins(plugins *plugin.Set) Option {
+	return func(n *Node) {
+		n.plugins = plugins
+	}
+}
+
+// pluginRules wraps each loaded plugin as a validation rule
+func (n *Node) pluginRules() []ValidationRule {
+	var rules []ValidationRule
+	for _, p := range n.plugins.Rules() {
+		rules = append(rules, ValidationRule{
+			Name:        "plugin." + p.Name,
+			Description: p.Description,
+			Severity:    p.Severity,
+			Check:       pluginCheck(p),
+		})
+	}
+	return rules
+}
+
+// pluginCheck runs a plugin against the claim data. A plugin that traps,
+// exceeds its limits or returns garbage fails the claim rather than letting
+// it through unchecked.
+func pluginCheck(p *plugin.Rule) func(context.Context, *Submission) []Violation {
+	return func(ctx context.Context, data *Submission) []Violation {
+		claim, err := json.Marshal(data.ClaimData)
+		if err != nil {
+			return violation("", nil, "Plugin input could not be encoded: %v", err)
+		}
+
+		result, err := p.Check(ctx, claim)
+		if err != nil {
+			return violation("", nil, "Plugin failed: %v", err)
+		}
+		if result.Pass {
+			return nil
+		}
+
+		message := result.Message
+		if message == "" {
+			message = "Rejected by plugin " + p.Name
+		}
+		return []Violation{{Path: result.Path, Message: message}}
+	}
+}
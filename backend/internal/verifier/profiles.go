@@ -0,0 +1,426 @@
+package verifier
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/saintparish4/apx/internal/codeset"
+	"github.com/saintparish4/apx/internal/domain"
+)
+
+var (
+	// Places of service are two-digit CMS codes (e.g. 11 office)
+	posRegex = regexp.MustCompile(`^\d{2}$`)
+
+	// UB-04 type of bill: an optional leading zero, then facility type,
+	// bill classification and frequency (e.g. 0111 hospital inpatient,
+	// admit through discharge)
+	typeOfBillRegex = regexp.MustCompile(`^0?([1-8])([1-9])([0-9A-Z])$`)
+
+	// Revenue codes are four digits (e.g. 0120 room and board, 0450
+	// emergency room)
+	revenueCodeRegex = regexp.MustCompile(`^\d{4}$`)
+
+	// MS-DRGs are three digits (e.g. 470)
+	drgRegex = regexp.MustCompile(`^\d{3}$`)
+
+	// Universal numbering: permanent teeth 1-32, primary teeth A-T, and
+	// supernumerary teeth 51-82 and AS-TS
+	toothRegex = regexp.MustCompile(`^([1-9]|[12]\d|3[0-2]|[A-T]|5[1-9]|[67]\d|8[0-2]|[A-T]S)$`)
+
+	// Tooth surfaces: mesial, occlusal, distal, buccal, lingual, incisal,
+	// facial
+	surfacesRegex = regexp.MustCompile(`^[MODBLIF]{1,5}$`)
+)
+
+// profileRules returns the rules of every claim type profile. Each applies
+// only to claims of its ClaimType and is named <claim type>.<rule>.
+func (n *Node) profileRules() []ValidationRule {
+	return []ValidationRule{
+		{
+			Name:        "professional.required_fields",
+			Description: "Professional claims must carry a place of service",
+			Severity:    "error",
+			ClaimType:   domain.ClaimTypeProfessional,
+			Check:       n.checkProfessionalFields,
+		},
+		{
+			Name:        "professional.place_of_service",
+			Description: "Place of service must be a CMS place of service code",
+			Severity:    "error",
+			ClaimType:   domain.ClaimTypeProfessional,
+			Check:       n.checkPlaceOfService,
+		},
+		{
+			Name:        "professional.code_systems",
+			Description: "Professional claims bill CPT and HCPCS procedures",
+			Severity:    "error",
+			ClaimType:   domain.ClaimTypeProfessional,
+			Check:       n.checkCodeSystems(codeset.CPT, codeset.HCPCS),
+		},
+		{
+			Name:        "institutional.required_fields",
+			Description: "Institutional claims must carry a type of bill, revenue lines and, for inpatient stays, admission and discharge dates",
+			Severity:    "error",
+			ClaimType:   domain.ClaimTypeInstitutional,
+			Check:       n.checkInstitutionalFields,
+		},
+		{
+			Name:        "institutional.type_of_bill",
+			Description: "Type of bill must be a valid UB-04 type of bill",
+			Severity:    "error",
+			ClaimType:   domain.ClaimTypeInstitutional,
+			Check:       n.checkTypeOfBill,
+		},
+		{
+			Name:        "institutional.revenue_codes",
+			Description: "Every service line must carry a four-digit revenue code",
+			Severity:    "error",
+			ClaimType:   domain.ClaimTypeInstitutional,
+			Check:       n.checkRevenueCodes,
+		},
+		{
+			Name:        "institutional.stay_dates",
+			Description: "Admission and discharge dates must be ordered, not in the future, and span the service date",
+			Severity:    "error",
+			ClaimType:   domain.ClaimTypeInstitutional,
+			Check:       n.checkStayDates,
+		},
+		{
+			Name:        "institutional.drg",
+			Description: "Inpatient hospital claims must carry a valid MS-DRG",
+			Severity:    "error",
+			ClaimType:   domain.ClaimTypeInstitutional,
+			Check:       n.checkDRG,
+		},
+		{
+			Name:        "institutional.code_systems",
+			Description: "Institutional claims bill CPT and HCPCS procedures",
+			Severity:    "error",
+			ClaimType:   domain.ClaimTypeInstitutional,
+			Check:       n.checkCodeSystems(codeset.CPT, codeset.HCPCS),
+		},
+		{
+			Name:        "dental.required_fields",
+			Description: "Dental claims must itemize service lines",
+			Severity:    "error",
+			ClaimType:   domain.ClaimTypeDental,
+			Check:       n.checkDentalFields,
+		},
+		{
+			Name:        "dental.code_systems",
+			Description: "Dental claims bill CDT procedures",
+			Severity:    "error",
+			ClaimType:   domain.ClaimTypeDental,
+			Check:       n.checkCodeSystems(codeset.CDT),
+		},
+		{
+			Name:        "dental.teeth",
+			Description: "Tooth numbers and surfaces must be valid and present where the procedure needs them",
+			Severity:    "error",
+			ClaimType:   domain.ClaimTypeDental,
+			Check:       n.checkTeeth,
+		},
+	}
+}
+
+func (n *Node) checkClaimType(ctx context.Context, data *Submission) []Violation {
+	if data.ClaimType == "" {
+		return nil // Reported by checkRequiredFields
+	}
+
+	for _, claimType := range domain.ClaimTypes {
+		if data.ClaimType == claimType {
+			return nil
+		}
+	}
+
+	return violation("claim_type", data.ClaimType, "Unknown claim type %q; expected one of %s",
+		data.ClaimType, strings.Join(domain.ClaimTypes, ", "))
+}
+
+// checkCodeSystems returns a check that each procedure belongs to one of the
+// code systems billed on the claim type, and that lines carry no fields of
+// other claim types
+func (n *Node) checkCodeSystems(systems ...codeset.System) func(context.Context, *Submission) []Violation {
+	labels := make([]string, len(systems))
+	for i, system := range systems {
+		labels[i] = systemLabel(system)
+	}
+
+	return func(ctx context.Context, data *Submission) []Violation {
+		violations := []Violation{}
+
+		for _, procedure := range submittedProcedures(data.ClaimData) {
+			base, _, ok := splitProcedureCode(procedure.Code)
+			if !ok || procedureSystem(base) == "" {
+				continue // Reported by checkProcedureCodes
+			}
+
+			system := procedureSystem(base)
+			if !containsSystem(systems, system) {
+				violations = append(violations, violation(procedure.Path, procedure.Code,
+					"%s code %s is not billed on %s claims (expected %s)",
+					systemLabel(system), base, data.ClaimType, strings.Join(labels, " or "))...)
+			}
+		}
+
+		for i, line := range data.ServiceLines {
+			if line.RevenueCode != "" && data.ClaimType != domain.ClaimTypeInstitutional {
+				violations = append(violations, violation(serviceLinePath(i, "revenue_code"), line.RevenueCode,
+					"Revenue codes are only billed on institutional claims")...)
+			}
+			if (line.Tooth != "" || line.Surfaces != "") && data.ClaimType != domain.ClaimTypeDental {
+				violations = append(violations, violation(serviceLinePath(i, "tooth"), line.Tooth,
+					"Tooth numbers and surfaces are only billed on dental claims")...)
+			}
+		}
+
+		return violations
+	}
+}
+
+func containsSystem(systems []codeset.System, system codeset.System) bool {
+	for _, s := range systems {
+		if s == system {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *Node) checkProfessionalFields(ctx context.Context, data *Submission) []Violation {
+	if data.PlaceOfService == "" {
+		return missingFields("place_of_service")
+	}
+	return nil
+}
+
+func (n *Node) checkPlaceOfService(ctx context.Context, data *Submission) []Violation {
+	if data.PlaceOfService == "" {
+		return nil // Reported by checkProfessionalFields
+	}
+
+	if !posRegex.MatchString(data.PlaceOfService) {
+		return violation("place_of_service", data.PlaceOfService, "Invalid place of service format (must be 2 digits)")
+	}
+
+	if ok, message := n.lookupCode(codeset.POS, data.PlaceOfService, parseServiceDate(data.ClaimData)); !ok {
+		return []Violation{{Path: "place_of_service", Value: data.PlaceOfService, Message: message}}
+	}
+
+	return nil
+}
+
+// typeOfBill is a parsed UB-04 type of bill
+type typeOfBill struct {
+	Facility       byte // 1 hospital, 2 skilled nursing, ...
+	Classification byte // 1 inpatient (Part A), 2 inpatient (Part B), 3 outpatient, ...
+	Frequency      byte // 1 admit through discharge, 2-4 interim, 7 replacement, 8 void, ...
+}
+
+func parseTypeOfBill(value string) (typeOfBill, bool) {
+	match := typeOfBillRegex.FindStringSubmatch(value)
+	if match == nil {
+		return typeOfBill{}, false
+	}
+	return typeOfBill{Facility: match[1][0], Classification: match[2][0], Frequency: match[3][0]}, true
+}
+
+// Inpatient reports whether the bill is for an inpatient hospital or
+// skilled nursing stay
+func (t typeOfBill) Inpatient() bool {
+	return (t.Facility == '1' || t.Facility == '2') && (t.Classification == '1' || t.Classification == '2')
+}
+
+// InpatientHospital reports whether the stay is paid by DRG
+func (t typeOfBill) InpatientHospital() bool {
+	return t.Facility == '1' && t.Classification == '1'
+}
+
+// Interim reports whether the bill covers part of a stay that has not ended
+func (t typeOfBill) Interim() bool {
+	return t.Frequency == '2' || t.Frequency == '3'
+}
+
+func (n *Node) checkInstitutionalFields(ctx context.Context, data *Submission) []Violation {
+	missing := []string{}
+
+	if data.TypeOfBill == "" {
+		missing = append(missing, "type_of_bill")
+	}
+	if len(data.ServiceLines) == 0 {
+		missing = append(missing, "service_lines")
+	}
+
+	if tob, ok := parseTypeOfBill(data.TypeOfBill); ok && tob.Inpatient() {
+		if data.AdmissionDate == "" {
+			missing = append(missing, "admission_date")
+		}
+		if data.DischargeDate == "" && !tob.Interim() {
+			missing = append(missing, "discharge_date")
+		}
+	}
+
+	return missingFields(missing...)
+}
+
+func (n *Node) checkTypeOfBill(ctx context.Context, data *Submission) []Violation {
+	if data.TypeOfBill == "" {
+		return nil // Reported by checkInstitutionalFields
+	}
+
+	if _, ok := parseTypeOfBill(data.TypeOfBill); !ok {
+		return violation("type_of_bill", data.TypeOfBill, "Invalid type of bill: %s", data.TypeOfBill)
+	}
+
+	return nil
+}
+
+func (n *Node) checkRevenueCodes(ctx context.Context, data *Submission) []Violation {
+	violations := []Violation{}
+
+	for i, line := range data.ServiceLines {
+		path := serviceLinePath(i, "revenue_code")
+		switch {
+		case line.RevenueCode == "":
+			violations = append(violations, violation(path, nil, "Missing revenue code")...)
+		case !revenueCodeRegex.MatchString(line.RevenueCode):
+			violations = append(violations, violation(path, line.RevenueCode,
+				"Invalid revenue code format: %s", line.RevenueCode)...)
+		}
+	}
+
+	return violations
+}
+
+func (n *Node) checkStayDates(ctx context.Context, data *Submission) []Violation {
+	violations := []Violation{}
+
+	admission, admissionOK := parseStayDate(data.AdmissionDate)
+	if data.AdmissionDate != "" && !admissionOK {
+		violations = append(violations, violation("admission_date", data.AdmissionDate,
+			"Invalid admission date format (YYYY-MM-DD)")...)
+	}
+	discharge, dischargeOK := parseStayDate(data.DischargeDate)
+	if data.DischargeDate != "" && !dischargeOK {
+		violations = append(violations, violation("discharge_date", data.DischargeDate,
+			"Invalid discharge date format (YYYY-MM-DD)")...)
+	}
+
+	if dischargeOK && discharge.After(data.now()) {
+		violations = append(violations, violation("discharge_date", data.DischargeDate,
+			"Discharge date cannot be in the future")...)
+	}
+	if admissionOK && dischargeOK && discharge.Before(admission) {
+		violations = append(violations, violation("discharge_date", data.DischargeDate,
+			"Discharge date %s is before admission date %s", data.DischargeDate, data.AdmissionDate)...)
+		return violations
+	}
+
+	serviceDate := parseServiceDate(data.ClaimData)
+	if serviceDate.IsZero() {
+		return violations // Reported by checkServiceDate
+	}
+	if admissionOK && serviceDate.Before(admission) {
+		violations = append(violations, violation("service_date", data.ServiceDate,
+			"Service date %s is before admission date %s", data.ServiceDate, data.AdmissionDate)...)
+	}
+	if dischargeOK && serviceDate.After(discharge) {
+		violations = append(violations, violation("service_date", data.ServiceDate,
+			"Service date %s is after discharge date %s", data.ServiceDate, data.DischargeDate)...)
+	}
+
+	return violations
+}
+
+// parseStayDate parses an admission or discharge date; ok is false when it
+// is missing or malformed
+func parseStayDate(value string) (time.Time, bool) {
+	date, err := time.Parse("2006-01-02", value)
+	return date, err == nil
+}
+
+func (n *Node) checkDRG(ctx context.Context, data *Submission) []Violation {
+	if data.DRGCode != "" {
+		if !drgRegex.MatchString(data.DRGCode) {
+			return violation("drg_code", data.DRGCode, "Invalid MS-DRG format (must be 3 digits)")
+		}
+		return nil
+	}
+
+	if tob, ok := parseTypeOfBill(data.TypeOfBill); ok && tob.InpatientHospital() {
+		return violation("drg_code", nil, "Inpatient hospital claims (type of bill %s) require an MS-DRG", data.TypeOfBill)
+	}
+
+	return nil
+}
+
+func (n *Node) checkDentalFields(ctx context.Context, data *Submission) []Violation {
+	// Tooth numbers and surfaces can only be given per line
+	if len(data.ServiceLines) == 0 {
+		return missingFields("service_lines")
+	}
+	return nil
+}
+
+func (n *Node) checkTeeth(ctx context.Context, data *Submission) []Violation {
+	violations := []Violation{}
+
+	for i, line := range data.ServiceLines {
+		code := strings.ToUpper(strings.TrimSpace(line.ProcedureCode))
+		tooth := strings.ToUpper(line.Tooth)
+		surfaces := strings.ToUpper(line.Surfaces)
+
+		switch {
+		case tooth == "" && toothSpecific(code):
+			violations = append(violations, violation(serviceLinePath(i, "tooth"), nil,
+				"%s requires a tooth number", code)...)
+		case tooth != "" && !toothRegex.MatchString(tooth):
+			violations = append(violations, violation(serviceLinePath(i, "tooth"), line.Tooth,
+				"Invalid tooth number: %s", line.Tooth)...)
+		}
+
+		switch {
+		case surfaces == "" && surfaceSpecific(code):
+			violations = append(violations, violation(serviceLinePath(i, "surfaces"), nil,
+				"%s requires tooth surfaces", code)...)
+		case surfaces != "" && (!surfacesRegex.MatchString(surfaces) || repeatsLetter(surfaces)):
+			violations = append(violations, violation(serviceLinePath(i, "surfaces"), line.Surfaces,
+				"Invalid tooth surfaces: %s", line.Surfaces)...)
+		case surfaces != "" && tooth == "":
+			violations = append(violations, violation(serviceLinePath(i, "surfaces"), line.Surfaces,
+				"Tooth surfaces given without a tooth number")...)
+		}
+	}
+
+	return violations
+}
+
+// toothSpecific reports whether a CDT code is performed on a single tooth:
+// restorations (D2xxx), endodontics (D3xxx) and extractions (D71xx, D72xx)
+func toothSpecific(code string) bool {
+	if !cdtRegex.MatchString(code) {
+		return false
+	}
+	return code[1] == '2' || code[1] == '3' || strings.HasPrefix(code, "D71") || strings.HasPrefix(code, "D72")
+}
+
+// surfaceSpecific reports whether a CDT code restores particular surfaces:
+// amalgam and resin-based composite restorations (D21xx-D23xx)
+func surfaceSpecific(code string) bool {
+	return cdtRegex.MatchString(code) && code[1] == '2' && code[2] >= '1' && code[2] <= '3'
+}
+
+func repeatsLetter(value string) bool {
+	seen := map[rune]bool{}
+	for _, r := range value {
+		if seen[r] {
+			return true
+		}
+		seen[r] = true
+	}
+	return false
+}
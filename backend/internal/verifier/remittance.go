@@ -0,0 +1,404 @@
+package verifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+	"github.com/saintparish4/apx/internal/domain"
+	"github.com/saintparish4/apx/internal/ethereum"
+	"github.com/saintparish4/apx/internal/store"
+	"github.com/saintparish4/apx/internal/x12"
+)
+
+// networkName names the network as sender and default payer of remittances
+const networkName = "APX CLAIMS NETWORK"
+
+// remittanceCode is the CARC and optional RARC a failed rule is reported
+// with on an 835
+type remittanceCode struct {
+	Reason string // claim adjustment reason code
+	Remark string // remittance advice remark code
+}
+
+// remittanceCodes maps rules to the adjustment codes their denials carry.
+// Plugin rules and rules not listed fall back to defaultRemittanceCode.
+var remittanceCodes = map[string]remittanceCode{
+	"valid_procedure_codes":           {"181", "M51"},
+	"valid_service_lines":             {"16", "M51"},
+	"valid_diagnosis_codes":           {"146", "M76"},
+	"valid_amount":                    {"16", "M79"},
+	"amount_matches_chain":            {"16", "M79"},
+	"data_hash_matches_chain":         {"16", "MA130"},
+	invalidClaimDataRule:              {"16", "MA130"},
+	"financial_consistency":           {"16", "M79"},
+	"valid_service_date":              {"16", "M52"},
+	"valid_npi":                       {"16", "N257"},
+	"active_npi":                      {"B7", "N257"},
+	"npi_matches_provider":            {"16", "N257"},
+	"valid_claim_type":                {"16", "MA130"},
+	"known_payer":                     {"16", "MA130"},
+	"payer_claim_type":                {"96", "N130"},
+	"payer_covered_procedures":        {"204", "N130"},
+	"has_required_fields":             {"16", "MA130"},
+	"ncci_procedure_pairs":            {"97", "M80"},
+	"medically_unlikely_units":        {"151", "N362"},
+	"no_duplicate_claim":              {"18", ""},
+	"possible_duplicate_claim":        {"18", ""},
+	"reasonable_amount_for_procedure": {"45", ""},
+	"readable_documents":              {"252", "N29"},
+	"required_documents":              {"252", "N29"},
+	"recommended_documents":           {"252", "N29"},
+	"diagnosis_procedure_match":       {"11", "M76"},
+	"professional.required_fields":    {"16", "MA130"},
+	"professional.place_of_service":   {"5", "M77"},
+	"professional.code_systems":       {"16", "M51"},
+	"institutional.required_fields":   {"16", "MA130"},
+	"institutional.type_of_bill":      {"16", "MA30"},
+	"institutional.revenue_codes":     {"16", "M50"},
+	"institutional.stay_dates":        {"16", "MA31"},
+	"institutional.drg":               {"16", "N208"},
+	"institutional.code_systems":      {"16", "M51"},
+	"dental.required_fields":          {"16", "MA130"},
+	"dental.code_systems":             {"16", "M51"},
+	"dental.teeth":                    {"16", "N37"},
+}
+
+// defaultRemittanceCode reports denials whose reason maps to no rule, such
+// as a reviewer's notes
+var defaultRemittanceCode = remittanceCode{Reason: "16"}
+
+// timelyFilingCode replaces valid_service_date's code when the claim was
+// filed too late rather than with a bad date
+var timelyFilingCode = remittanceCode{Reason: "29"}
+
+// remittanceCodeFor returns the codes a failed rule is reported with
+func remittanceCodeFor(rule, message string) remittanceCode {
+	if rule == "valid_service_date" && strings.Contains(message, "timely filing") {
+		return timelyFilingCode
+	}
+	if code, ok := remittanceCodes[rule]; ok {
+		return code
+	}
+	return defaultRemittanceCode
+}
+
+// AdjustmentReason returns the CARC and RARC (empty when none applies) a
+// failed rule is reported with
+func AdjustmentReason(rule, message string) (reason, remark string) {
+	code := remittanceCodeFor(rule, message)
+	return code.Reason, code.Remark
+}
+
+// remittanceScanLimit bounds the claims read from the chain for one
+// remittance, each costing a getClaim call
+const remittanceScanLimit = 5000
+
+// ErrRemittanceTooLarge is returned when a remittance's date range spans
+// more than remittanceScanLimit claims
+var ErrRemittanceTooLarge = fmt.Errorf("remittance spans more than %d claims; narrow the date range", remittanceScanLimit)
+
+// Remittance builds 835 remittance advice for a provider's claims finalized
+// (approved or rejected on-chain) between from and to inclusive. Claims are
+// grouped into one payment per payer.
+//
+// Votes finalize a claim within the verification window, so only claims
+// submitted since from less the window are read, newest first; claims an
+// admin finalized later than that are not covered. Claim data is fetched
+// from IPFS only for claims finalized in the range.
+func (n *Node) Remittance(ctx context.Context, provider common.Address, from, to time.Time) (*x12.Remittance, error) {
+	ids, err := n.ethService.GetProviderClaims(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	// ids are oldest first
+	var finalized []*domain.Claim
+	for i := len(ids) - 1; i >= 0; i-- {
+		claim, err := n.ethService.GetClaim(ctx, ids[i])
+		if err != nil {
+			return nil, err
+		}
+		if claim.SubmittedAt.Add(ethereum.VerificationWindow).Before(from) {
+			break
+		}
+		if len(ids)-i > remittanceScanLimit {
+			return nil, ErrRemittanceTooLarge
+		}
+		if claim.Status != domain.ClaimStatusApproved && claim.Status != domain.ClaimStatusRejected {
+			continue
+		}
+		if claim.VerifiedAt.Before(from) || claim.VerifiedAt.After(to) {
+			continue
+		}
+		finalized = append(finalized, claim)
+	}
+
+	remittance := &x12.Remittance{
+		SenderID:      "APX",
+		ReceiverID:    strings.ToUpper(provider.Hex()[2:17]),
+		ControlNumber: int(time.Now().Unix() % 1000000000),
+		Created:       time.Now().UTC(),
+		PayeeName:     provider.Hex(),
+	}
+	payments := map[string]*x12.Payment{}
+	claimedNPI := "" // the first NPI a claim names, should the provider have none registered
+
+	// Claims are listed oldest first
+	for i := len(finalized) - 1; i >= 0; i-- {
+		claim := finalized[i]
+		claimData, err := n.ipfsService.RetrieveClaimData(ctx, claim.IPFSCID)
+		if err != nil {
+			// The on-chain record alone still yields a remittance line
+			log.Warn().Err(err).Str("claim_id", common.Hash(claim.ClaimID).Hex()).Msg("Failed to retrieve claim data for remittance")
+			claimData = &domain.ClaimData{}
+		}
+
+		if claimedNPI == "" {
+			claimedNPI = claimData.ProviderNPI
+		}
+
+		payment, ok := payments[claimData.PayerID]
+		if !ok {
+			payment = &x12.Payment{PayerID: claimData.PayerID, PayerName: n.payerName(claimData.PayerID)}
+			payments[claimData.PayerID] = payment
+		}
+		payment.Claims = append(payment.Claims, n.claimPayment(ctx, claim, claimData))
+	}
+
+	n.resolvePayee(ctx, provider, claimedNPI, remittance)
+
+	for _, payment := range payments {
+		remittance.Payments = append(remittance.Payments, payment)
+	}
+	sort.Slice(remittance.Payments, func(i, j int) bool {
+		return remittance.Payments[i].PayerID < remittance.Payments[j].PayerID
+	})
+
+	return remittance, nil
+}
+
+// claimPayment adjudicates a finalized claim for the remittance. The
+// on-chain amount is the charge; approved claims pay the allowed amount less
+// patient responsibility, rejected claims pay nothing.
+func (n *Node) claimPayment(ctx context.Context, claim *domain.Claim, data *domain.ClaimData) *x12.ClaimPayment {
+	claimID := common.Hash(claim.ClaimID).Hex()
+
+	charge, err := domain.MoneyFromWei(claim.Amount)
+	if err != nil && data.BilledAmount != nil {
+		charge = *data.BilledAmount
+	}
+
+	payment := &x12.ClaimPayment{
+		PatientControlNumber: data.PatientControlNumber,
+		// CLP07 holds 50 characters; a 200-bit prefix of the claim ID is
+		// still unique
+		PayerClaimID: claimID[2:52],
+		Charge:       charge,
+		PatientID:    data.PatientID,
+		ServiceDate:  data.ServiceDate,
+		Received:     claim.SubmittedAt,
+		Frequency:    "1",
+	}
+	if payment.PatientControlNumber == "" {
+		payment.PatientControlNumber = claimID[2:40]
+	}
+	if data.OriginalClaimID != "" {
+		payment.Frequency = "7"
+	}
+
+	switch data.ClaimType {
+	case domain.ClaimTypeInstitutional:
+		if bill, ok := parseTypeOfBill(data.TypeOfBill); ok {
+			payment.FacilityCode = string([]byte{bill.Facility, bill.Classification})
+			payment.Frequency = string(bill.Frequency)
+			payment.Inpatient = bill.Inpatient()
+		}
+	default:
+		payment.FacilityCode = data.PlaceOfService
+	}
+
+	if claim.Status == domain.ClaimStatusRejected {
+		payment.Status = x12.ClaimDenied
+		n.denialAdjustments(ctx, claim, payment)
+		return payment
+	}
+
+	payment.Status = x12.ClaimProcessed
+	approvedAdjustments(data, payment)
+	return payment
+}
+
+// approvedAdjustments reduces the charge to the allowed amount (CO-45) and
+// moves deductible (PR-1) and copay (PR-3) to the patient
+func approvedAdjustments(data *domain.ClaimData, payment *x12.ClaimPayment) {
+	allowed := payment.Charge
+	if data.AllowedAmount != nil && *data.AllowedAmount < allowed {
+		allowed = *data.AllowedAmount
+	}
+	if reduction := payment.Charge - allowed; reduction > 0 {
+		payment.Adjustments = append(payment.Adjustments, x12.Adjustment{Group: x12.GroupContractual, Reason: "45", Amount: reduction})
+	}
+
+	remaining := allowed
+	for _, share := range []struct {
+		reason string
+		amount *domain.Money
+	}{{"1", data.DeductibleAmount}, {"3", data.CopayAmount}} {
+		if share.amount == nil || *share.amount <= 0 || remaining == 0 {
+			continue
+		}
+		amount := *share.amount
+		if amount > remaining {
+			amount = remaining
+		}
+		payment.Adjustments = append(payment.Adjustments, x12.Adjustment{Group: x12.GroupPatientResponsibility, Reason: share.reason, Amount: amount})
+		payment.PatientResponsibility += amount
+		remaining -= amount
+	}
+
+	payment.Paid = remaining
+}
+
+// denialAdjustments adjusts the whole charge off under the codes of the
+// rules that rejected the claim: this node's stored findings when it voted to
+// reject, otherwise the rules named in the on-chain rejectionReason
+func (n *Node) denialAdjustments(ctx context.Context, claim *domain.Claim, payment *x12.ClaimPayment) {
+	codes := n.verdictCodes(ctx, common.Hash(claim.ClaimID).Hex())
+	if len(codes) == 0 {
+		codes = n.rejectionReasonCodes(claim.RejectionReason)
+	}
+	if len(codes) == 0 {
+		codes = []remittanceCode{defaultRemittanceCode}
+	}
+
+	// The charge is adjusted once; further reasons are listed at zero
+	seenReasons, seenRemarks := map[string]bool{}, map[string]bool{}
+	for _, code := range codes {
+		if !seenReasons[code.Reason] {
+			seenReasons[code.Reason] = true
+			amount := domain.Money(0)
+			if len(payment.Adjustments) == 0 {
+				amount = payment.Charge
+			}
+			payment.Adjustments = append(payment.Adjustments, x12.Adjustment{Group: x12.GroupContractual, Reason: code.Reason, Amount: amount})
+		}
+		if code.Remark != "" && !seenRemarks[code.Remark] {
+			seenRemarks[code.Remark] = true
+			payment.Remarks = append(payment.Remarks, code.Remark)
+		}
+	}
+}
+
+// verdictCodes returns the codes of the rules this node rejected a claim for
+func (n *Node) verdictCodes(ctx context.Context, claimID string) []remittanceCode {
+	if n.verdicts == nil {
+		return nil
+	}
+
+	verdict, err := n.verdicts.GetVerdict(ctx, claimID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("claim_id", claimID).Msg("Failed to load verdict for remittance")
+		return nil
+	}
+	if verdict.Result == nil || verdict.Result.Approved {
+		return nil
+	}
+
+	// Error findings fail a claim; without any it fell below the score
+	// threshold on warnings
+	var codes []remittanceCode
+	for _, severity := range []string{"error", "warning"} {
+		for _, finding := range verdict.Result.Findings {
+			if finding.Severity == severity {
+				codes = append(codes, remittanceCodeFor(finding.Rule, finding.Message))
+			}
+		}
+		if len(codes) > 0 {
+			break
+		}
+	}
+	return codes
+}
+
+// rejectionReasonCodes returns the codes of the rules named in an on-chain
+// rejection reason, as written by voteReason: "Rejected: rule, rule; ruleset
+// version"
+func (n *Node) rejectionReasonCodes(reason string) []remittanceCode {
+	rules, ok := strings.CutPrefix(reason, "Rejected: ")
+	if !ok {
+		return nil
+	}
+	rules, _, _ = strings.Cut(rules, "; ")
+
+	var codes []remittanceCode
+	for _, rule := range strings.Split(rules, ", ") {
+		if n.isRule(rule) {
+			codes = append(codes, remittanceCodeFor(rule, ""))
+		}
+	}
+	return codes
+}
+
+func (n *Node) isRule(name string) bool {
+	if name == invalidClaimDataRule {
+		return true
+	}
+	for _, rule := range n.rules {
+		if rule.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// payerName names a payer on the remittance
+func (n *Node) payerName(payerID string) string {
+	if profile, ok := n.payers.Lookup(payerID); ok && profile.Name != "" {
+		return profile.Name
+	}
+	if payerID != "" {
+		return payerID
+	}
+	return networkName
+}
+
+// resolvePayee fills in the payee's NPI and NPPES name. The NPI registered
+// for the provider's wallet is used over claimedNPI, which claims report
+// themselves.
+func (n *Node) resolvePayee(ctx context.Context, provider common.Address, claimedNPI string, remittance *x12.Remittance) {
+	remittance.PayeeNPI = claimedNPI
+	if n.providers == nil {
+		return
+	}
+
+	registered, err := n.providers.GetProviderNPI(ctx, provider)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		log.Warn().Err(err).Str("provider", provider.Hex()).Msg("Provider NPI lookup failed")
+	}
+	if registered != "" {
+		remittance.PayeeNPI = registered
+	}
+	if remittance.PayeeNPI == "" {
+		return
+	}
+
+	record, err := n.providers.GetNPPESRecord(ctx, remittance.PayeeNPI)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			log.Warn().Err(err).Str("npi", remittance.PayeeNPI).Msg("NPPES lookup failed")
+		}
+		return
+	}
+	if record.Name != "" {
+		remittance.PayeeName = fmt.Sprintf("%.60s", strings.ToUpper(record.Name))
+	}
+}
@@ -0,0 +1,143 @@
+package verifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+	"github.com/saintparish4/apx/internal/domain"
+)
+
+// ErrUnknownRuleset is returned when a replay names a ruleset the node has
+// not loaded
+var ErrUnknownRuleset = errors.New("unknown ruleset")
+
+// ErrNoVerdictStore is returned when replaying a claim without verdict history
+var ErrNoVerdictStore = errors.New("verdict store not configured")
+
+// VerdictStore persists the validation result of every on-chain claim
+type VerdictStore interface {
+	SaveVerdict(ctx context.Context, verdict *domain.Verdict) error
+	GetVerdict(ctx context.Context, claimID string) (*domain.Verdict, error)
+}
+
+// WithVerdictStore keeps verdicts so they can be audited and replayed
+func WithVerdictStore(verdicts VerdictStore) Option {
+	return func(n *Node) {
+		n.verdicts = verdicts
+	}
+}
+
+// recordVerdict stores the result of validating an on-chain claim
+func (n *Node) recordVerdict(ctx context.Context, sub *Submission, ipfsCID string, result *domain.ValidationResult) {
+	if n.verdicts == nil {
+		return
+	}
+
+	verdict := &domain.Verdict{
+		ClaimID:  common.Hash(sub.ClaimID).Hex(),
+		IPFSCID:  ipfsCID,
+		Provider: sub.Provider,
+		Amount:   sub.Amount,
+		Result:   result,
+	}
+	if sub.DataHash != ([32]byte{}) {
+		dataHash := common.Hash(sub.DataHash)
+		verdict.DataHash = &dataHash
+	}
+	if err := n.verdicts.SaveVerdict(ctx, verdict); err != nil {
+		log.Warn().Err(err).Str("claim_id", verdict.ClaimID).Msg("Failed to save verdict")
+	}
+}
+
+// Verdict returns the stored validation result of an on-chain claim
+func (n *Node) Verdict(ctx context.Context, claimID string) (*domain.Verdict, error) {
+	if n.verdicts == nil {
+		return nil, ErrNoVerdictStore
+	}
+	return n.verdicts.GetVerdict(ctx, claimID)
+}
+
+// Replay validates a submission under a pinned ruleset with the clock set to
+// asOf. Provider baselines are not versioned, so anomaly scoring is skipped.
+func (n *Node) Replay(ctx context.Context, sub *Submission, version string, asOf time.Time) (*domain.ValidationResult, error) {
+	rs, ok := n.RulesetByVersion(version)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownRuleset, version)
+	}
+
+	sub.ruleset = rs
+	sub.AsOf = asOf
+	if sub.AsOf.IsZero() {
+		sub.AsOf = time.Now()
+	}
+
+	return n.ValidateSubmission(ctx, sub), nil
+}
+
+// ReplayResult compares a stored verdict with a fresh run under a pinned
+// ruleset
+type ReplayResult struct {
+	ClaimID     string                   `json:"claim_id"`
+	Original    *domain.ValidationResult `json:"original"`
+	Replay      *domain.ValidationResult `json:"replay"`
+	SameRuleset bool                     `json:"same_ruleset"` // ruleset hashes match
+	SameVerdict bool                     `json:"same_verdict"` // approval, score and risk level match
+}
+
+// ReplayClaim re-runs a stored on-chain claim under the ruleset version it
+// was originally validated with, or under version if one is given
+func (n *Node) ReplayClaim(ctx context.Context, claimID string, version string) (*ReplayResult, error) {
+	if n.verdicts == nil {
+		return nil, ErrNoVerdictStore
+	}
+
+	verdict, err := n.verdicts.GetVerdict(ctx, claimID)
+	if err != nil {
+		return nil, err
+	}
+
+	original := verdict.Result
+	asOf := time.Time{}
+	if original.Ruleset != nil {
+		asOf = original.Ruleset.EvaluatedAt
+		if version == "" {
+			version = original.Ruleset.Version
+		}
+	}
+	if version == "" {
+		version = n.ruleset.Version
+	}
+
+	claimData, err := n.ipfsService.RetrieveClaimData(ctx, verdict.IPFSCID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve claim data: %w", err)
+	}
+
+	sub := &Submission{
+		ClaimData: claimData,
+		ClaimID:   common.HexToHash(verdict.ClaimID),
+		Provider:  verdict.Provider,
+		Amount:    verdict.Amount,
+	}
+	if verdict.DataHash != nil {
+		sub.DataHash = *verdict.DataHash
+	}
+	replay, err := n.Replay(ctx, sub, version, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReplayResult{
+		ClaimID:     verdict.ClaimID,
+		Original:    original,
+		Replay:      replay,
+		SameRuleset: original.Ruleset != nil && original.Ruleset.Hash == replay.Ruleset.Hash,
+		SameVerdict: original.Approved == replay.Approved &&
+			original.Score == replay.Score &&
+			original.RiskLevel == replay.RiskLevel,
+	}, nil
+}
@@ -0,0 +1,351 @@
+package verifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+	"github.com/saintparish4/apx/internal/domain"
+	"github.com/saintparish4/apx/internal/ethereum"
+	"github.com/saintparish4/apx/internal/store"
+)
+
+var (
+	// ErrNoReviewQueue is returned by review operations when no queue is configured
+	ErrNoReviewQueue = errors.New("review queue not configured")
+
+	// ErrReviewClosed is returned when a review is already decided or expired
+	ErrReviewClosed = errors.New("review is closed")
+
+	// ErrReviewTaken is returned when a review is claimed by another reviewer
+	ErrReviewTaken = errors.New("review is claimed by another reviewer")
+
+	// ErrReasonTooLong is returned when a review's vote reason exceeds
+	// ethereum.MaxReasonLength
+	ErrReasonTooLong = fmt.Errorf("vote reason exceeds %d bytes", ethereum.MaxReasonLength)
+)
+
+// reviewReasonPrefix marks votes cast by a human reviewer
+const reviewReasonPrefix = "Manual review: "
+
+// ReviewQueue persists claims parked for human review
+type ReviewQueue interface {
+	EnqueueReview(ctx context.Context, review *domain.Review) error
+	GetReview(ctx context.Context, claimID string) (*domain.Review, error)
+	ListReviews(ctx context.Context, status domain.ReviewStatus, limit, offset int) ([]*domain.Review, error)
+	ClaimReview(ctx context.Context, claimID, reviewer string) (*domain.Review, error)
+	AddReviewNote(ctx context.Context, claimID string, note domain.ReviewNote) (*domain.Review, error)
+	DecideReview(ctx context.Context, claimID, reviewer string, approved bool, reason string) (*domain.Review, error)
+	DecideOverdueReview(ctx context.Context, claimID string, approved bool, reason string) (*domain.Review, error)
+	BeginReviewSubmission(ctx context.Context, claimID string) (*domain.Review, error)
+	ReleaseReviewSubmission(ctx context.Context, claimID string) (*domain.Review, error)
+	MarkReviewSubmitted(ctx context.Context, claimID string, txHash common.Hash) (*domain.Review, error)
+	ExpireReviews(ctx context.Context) (int64, error)
+}
+
+// WithReviewQueue parks claims matching the ruleset's review policy for a
+// human reviewer instead of voting on them automatically
+func WithReviewQueue(queue ReviewQueue) Option {
+	return func(n *Node) {
+		n.reviews = queue
+	}
+}
+
+// matches reports whether a validated claim needs human review
+func (p ReviewPolicy) matches(sub *Submission, result *domain.ValidationResult) bool {
+	for _, level := range p.RiskLevels {
+		if result.RiskLevel == level {
+			return true
+		}
+	}
+
+	for _, finding := range result.Findings {
+		for _, rule := range p.Rules {
+			if finding.Rule == rule {
+				return true
+			}
+		}
+	}
+
+	if p.MinAmount > 0 && sub.BilledAmount != nil && *sub.BilledAmount >= p.MinAmount {
+		return true
+	}
+
+	return false
+}
+
+// routeClaim votes on a validated claim, or parks it for review when the
+// review policy asks for a human decision
+func (n *Node) routeClaim(ctx context.Context, sub *Submission, event *ethereum.ClaimEvent, result *domain.ValidationResult) {
+	if n.reviews == nil || !sub.ruleset.Review.matches(sub, result) {
+		n.vote(ctx, sub.ClaimID, result.Approved, voteReason(result))
+		return
+	}
+
+	submittedAt := event.SubmittedAt
+	if submittedAt.IsZero() {
+		submittedAt = time.Now().UTC()
+	}
+	windowCloses := submittedAt.Add(ethereum.VerificationWindow)
+
+	review := &domain.Review{
+		ClaimID:        common.Hash(sub.ClaimID).Hex(),
+		IPFSCID:        event.IPFSCID,
+		Provider:       sub.Provider,
+		Result:         result,
+		Deadline:       windowCloses.Add(-time.Duration(sub.ruleset.Review.DeadlineHours) * time.Hour),
+		WindowClosesAt: windowCloses,
+	}
+
+	if err := n.reviews.EnqueueReview(ctx, review); err != nil {
+		// Better an automatic vote than none before the window closes
+		log.Error().Err(err).Str("claim_id", review.ClaimID).Msg("Failed to enqueue review, voting automatically")
+		n.vote(ctx, sub.ClaimID, result.Approved, voteReason(result))
+		return
+	}
+
+	log.Info().
+		Str("claim_id", review.ClaimID).
+		Str("risk_level", result.RiskLevel).
+		Time("deadline", review.Deadline).
+		Msg("Claim parked for human review")
+}
+
+// vote submits this node's verification on-chain when it has a signing key
+func (n *Node) vote(ctx context.Context, claimID [32]byte, approved bool, reason string) (common.Hash, error) {
+	if !n.ethService.CanVote() {
+		return common.Hash{}, errors.New("no signing key configured")
+	}
+
+	txHash, err := n.ethService.SubmitVerification(ctx, claimID, approved, reason)
+	if err != nil {
+		log.Error().Err(err).Str("claim_id", common.Hash(claimID).Hex()).Msg("Failed to submit verification")
+		return common.Hash{}, err
+	}
+
+	log.Info().
+		Str("claim_id", common.Hash(claimID).Hex()).
+		Bool("approved", approved).
+		Str("tx_hash", txHash.Hex()).
+		Msg("Verification submitted")
+	return txHash, nil
+}
+
+// voteReason summarizes an automatic verdict for the on-chain vote. The chain
+// is public, so only rule names and the ruleset version go on it; messages,
+// which quote codes, dates and amounts, stay in the verdict store.
+func voteReason(result *domain.ValidationResult) string {
+	version := ""
+	if result.Ruleset != nil {
+		version = "; ruleset " + result.Ruleset.Version
+	}
+
+	if result.Approved {
+		return "Approved" + version
+	}
+
+	// Error findings fail a claim; without any it fell below the score
+	// threshold on warnings
+	var rules []string
+	seen := map[string]bool{}
+	for _, severity := range []string{"error", "warning"} {
+		for _, finding := range result.Findings {
+			if finding.Severity == severity && !seen[finding.Rule] {
+				seen[finding.Rule] = true
+				rules = append(rules, finding.Rule)
+			}
+		}
+		if len(rules) > 0 {
+			break
+		}
+	}
+	if len(rules) == 0 {
+		return "Rejected" + version
+	}
+	return "Rejected: " + strings.Join(rules, ", ") + version
+}
+
+// ListReviews returns reviews in a status (all when empty), most urgent first
+func (n *Node) ListReviews(ctx context.Context, status domain.ReviewStatus, limit, offset int) ([]*domain.Review, error) {
+	if n.reviews == nil {
+		return nil, ErrNoReviewQueue
+	}
+	return n.reviews.ListReviews(ctx, status, limit, offset)
+}
+
+// GetReview returns a single review
+func (n *Node) GetReview(ctx context.Context, claimID string) (*domain.Review, error) {
+	if n.reviews == nil {
+		return nil, ErrNoReviewQueue
+	}
+	return n.reviews.GetReview(ctx, claimID)
+}
+
+// ClaimReview assigns a review to a reviewer
+func (n *Node) ClaimReview(ctx context.Context, claimID, reviewer string) (*domain.Review, error) {
+	if n.reviews == nil {
+		return nil, ErrNoReviewQueue
+	}
+
+	review, err := n.reviews.ClaimReview(ctx, claimID, reviewer)
+	if errors.Is(err, store.ErrConflict) {
+		return nil, n.reviewConflict(ctx, claimID)
+	}
+	return review, err
+}
+
+// AnnotateReview adds a note to an open review
+func (n *Node) AnnotateReview(ctx context.Context, claimID, author, note string) (*domain.Review, error) {
+	if n.reviews == nil {
+		return nil, ErrNoReviewQueue
+	}
+
+	review, err := n.reviews.AddReviewNote(ctx, claimID, domain.ReviewNote{
+		Author:    author,
+		Note:      note,
+		CreatedAt: time.Now().UTC(),
+	})
+	if errors.Is(err, store.ErrConflict) {
+		return nil, ErrReviewClosed
+	}
+	return review, err
+}
+
+// DecideReview records a reviewer's decision and submits it on-chain with
+// the given reason; the review's notes stay off-chain. If submission fails
+// the review stays decided and is retried in the background until the window
+// closes.
+func (n *Node) DecideReview(ctx context.Context, claimID, reviewer string, approved bool, reason string) (*domain.Review, error) {
+	if n.reviews == nil {
+		return nil, ErrNoReviewQueue
+	}
+	if len(reviewReasonPrefix+reason) > ethereum.MaxReasonLength {
+		return nil, ErrReasonTooLong
+	}
+
+	review, err := n.reviews.DecideReview(ctx, claimID, reviewer, approved, reviewReasonPrefix+reason)
+	if errors.Is(err, store.ErrConflict) {
+		return nil, n.reviewConflict(ctx, claimID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if submitted, err := n.submitReview(ctx, review); err == nil {
+		review = submitted
+	}
+	return review, nil
+}
+
+// submitReview votes on a decided review with its stored reason and marks it
+// submitted. The review is held in submitting while the vote is cast, so a
+// review decided as the background retry runs is voted on once.
+func (n *Node) submitReview(ctx context.Context, review *domain.Review) (*domain.Review, error) {
+	review, err := n.reviews.BeginReviewSubmission(ctx, review.ClaimID)
+	if err != nil {
+		return nil, err
+	}
+
+	txHash, err := n.vote(ctx, common.HexToHash(review.ClaimID), *review.Approved, review.Reason)
+	if err != nil {
+		if _, releaseErr := n.reviews.ReleaseReviewSubmission(ctx, review.ClaimID); releaseErr != nil {
+			log.Error().Err(releaseErr).Str("claim_id", review.ClaimID).Msg("Failed to release review submission")
+		}
+		return nil, err
+	}
+
+	submitted, err := n.reviews.MarkReviewSubmitted(ctx, review.ClaimID, txHash)
+	if err != nil {
+		log.Error().Err(err).Str("claim_id", review.ClaimID).Msg("Failed to mark review submitted")
+		return nil, err
+	}
+	return submitted, nil
+}
+
+// reviewConflict explains why a review could not be claimed or decided
+func (n *Node) reviewConflict(ctx context.Context, claimID string) error {
+	review, err := n.reviews.GetReview(ctx, claimID)
+	if err != nil {
+		return err
+	}
+	if review.Status == domain.ReviewClaimed && time.Now().Before(review.Deadline) {
+		return fmt.Errorf("%w: %s", ErrReviewTaken, review.Reviewer)
+	}
+	return ErrReviewClosed
+}
+
+// reviewLoop votes the automatic verdict on reviews left open past their
+// deadline, retries votes for decided reviews that failed to submit and for
+// submissions abandoned past their lease, and expires reviews whose window
+// closed without a vote
+func (n *Node) reviewLoop(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if expired, err := n.reviews.ExpireReviews(ctx); err != nil {
+			log.Warn().Err(err).Msg("Failed to expire reviews")
+		} else if expired > 0 {
+			log.Warn().Int64("expired", expired).Msg("Reviews expired before a vote was submitted")
+		}
+
+		if !n.ethService.CanVote() {
+			continue
+		}
+
+		n.decideOverdueReviews(ctx)
+
+		for _, status := range []domain.ReviewStatus{domain.ReviewDecided, domain.ReviewSubmitting} {
+			reviews, err := n.reviews.ListReviews(ctx, status, 100, 0)
+			if err != nil {
+				log.Warn().Err(err).Str("status", string(status)).Msg("Failed to list reviews to submit")
+				continue
+			}
+			// Reviews another submission holds are skipped with ErrConflict
+			for _, review := range reviews {
+				n.submitReview(ctx, review)
+			}
+		}
+	}
+}
+
+// decideOverdueReviews falls back to the automatic verdict for reviews no one
+// decided by their deadline and submits it
+func (n *Node) decideOverdueReviews(ctx context.Context) {
+	now := time.Now()
+	for _, status := range []domain.ReviewStatus{domain.ReviewPending, domain.ReviewClaimed} {
+		reviews, err := n.reviews.ListReviews(ctx, status, 100, 0)
+		if err != nil {
+			log.Warn().Err(err).Str("status", string(status)).Msg("Failed to list overdue reviews")
+			continue
+		}
+
+		// Listed by deadline, so the overdue reviews come first
+		for _, review := range reviews {
+			if review.Deadline.After(now) {
+				break
+			}
+
+			decided, err := n.reviews.DecideOverdueReview(ctx, review.ClaimID, review.Result.Approved, voteReason(review.Result))
+			if err != nil {
+				if !errors.Is(err, store.ErrConflict) {
+					log.Warn().Err(err).Str("claim_id", review.ClaimID).Msg("Failed to decide overdue review")
+				}
+				continue
+			}
+
+			log.Warn().Str("claim_id", review.ClaimID).Msg("Review deadline passed, voting the automatic verdict")
+			n.submitReview(ctx, decided)
+		}
+	}
+}
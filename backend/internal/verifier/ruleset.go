@@ -0,0 +1,228 @@
+package verifier
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/saintparish4/apx/internal/anomaly"
+	"github.com/saintparish4/apx/internal/domain"
+	"github.com/saintparish4/apx/internal/refdata"
+)
+
+// Ruleset holds the tunable parameters of the validation rules. Together with
+// the rule definitions and the loaded reference data it determines a verdict,
+// so bump Version whenever the parameters or rule behaviour change.
+type Ruleset struct {
+	Version string `json:"version"`
+
+	// Scoring
+	ErrorPenalty    float64 `json:"error_penalty"`     // points per failed error rule
+	WarningPenalty  float64 `json:"warning_penalty"`   // points per failed warning rule
+	ApprovalScore   float64 `json:"approval_score"`    // minimum score to approve
+	HighRiskScore   float64 `json:"high_risk_score"`   // scores below this are high risk
+	MediumRiskScore float64 `json:"medium_risk_score"` // scores below this are at least medium risk
+
+	// Rule thresholds
+	MaxClaimAmount    domain.Money `json:"max_claim_amount"` // must not exceed the contract's MAX_CLAIM_AMOUNT
+	MaxServiceAgeDays int          `json:"max_service_age_days"`
+	FeeLocality       string       `json:"fee_locality"`
+	FeeMaxMultiple    float64      `json:"fee_max_multiple"`
+
+	Anomaly anomaly.Config `json:"anomaly"`
+	Review  ReviewPolicy   `json:"review"`
+
+	DisabledRules []string `json:"disabled_rules,omitempty"`
+}
+
+// ReviewPolicy decides which claims are parked for a human reviewer instead
+// of being voted on automatically. A claim matching any criterion is parked.
+type ReviewPolicy struct {
+	RiskLevels    []string     `json:"risk_levels"`              // e.g. medium
+	Rules         []string     `json:"rules,omitempty"`          // claims with findings from these rules
+	MinAmount     domain.Money `json:"min_amount,omitempty"`     // claims billed at or above this amount
+	DeadlineHours int          `json:"deadline_hours,omitempty"` // hours before the window closes that decisions are due
+}
+
+// DefaultRuleset returns the parameters used when no ruleset file is loaded
+func DefaultRuleset() *Ruleset {
+	return &Ruleset{
+		Version:           "default",
+		ErrorPenalty:      15,
+		WarningPenalty:    5,
+		ApprovalScore:     60,
+		HighRiskScore:     50,
+		MediumRiskScore:   80,
+		MaxClaimAmount:    domain.MaxClaimAmount,
+		MaxServiceAgeDays: 365,
+		FeeLocality:       "00",
+		FeeMaxMultiple:    3.0,
+		Anomaly:           anomaly.DefaultConfig(),
+		Review: ReviewPolicy{
+			RiskLevels:    []string{"medium"},
+			DeadlineHours: 12,
+		},
+	}
+}
+
+// Disabled reports whether a rule is switched off in the ruleset
+func (rs *Ruleset) Disabled(rule string) bool {
+	for _, name := range rs.DisabledRules {
+		if name == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadRuleset reads a ruleset from a JSON file. Parameters the file omits
+// keep their defaults, and the version defaults to the file name.
+func LoadRuleset(path string) (*Ruleset, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rs := DefaultRuleset()
+	rs.Version = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(rs); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if rs.MaxClaimAmount > domain.MaxClaimAmount {
+		return nil, fmt.Errorf("%s: max_claim_amount %s exceeds the contract maximum of %s", path, rs.MaxClaimAmount, domain.MaxClaimAmount)
+	}
+
+	return rs, nil
+}
+
+// LoadRulesets reads every .json ruleset in dir, keyed by version
+func LoadRulesets(dir string) (map[string]*Ruleset, error) {
+	rulesets := map[string]*Ruleset{}
+
+	files, err := refdata.ListFiles(dir, ".json")
+	if errors.Is(err, os.ErrNotExist) {
+		return rulesets, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rulesets: %w", err)
+	}
+
+	for _, file := range files {
+		rs, err := LoadRuleset(file)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := rulesets[rs.Version]; exists {
+			return nil, fmt.Errorf("%s: duplicate ruleset version %s", file, rs.Version)
+		}
+		rulesets[rs.Version] = rs
+	}
+
+	return rulesets, nil
+}
+
+// WithRuleset sets the active ruleset
+func WithRuleset(rs *Ruleset) Option {
+	return func(n *Node) {
+		n.ruleset = rs
+	}
+}
+
+// WithRulesets makes historical rulesets available for replays
+func WithRulesets(rulesets map[string]*Ruleset) Option {
+	return func(n *Node) {
+		for version, rs := range rulesets {
+			n.rulesets[version] = rs
+		}
+	}
+}
+
+// Ruleset returns the active ruleset
+func (n *Node) Ruleset() *Ruleset {
+	return n.ruleset
+}
+
+// RulesetByVersion returns a loaded ruleset, active or historical
+func (n *Node) RulesetByVersion(version string) (*Ruleset, bool) {
+	if version == n.ruleset.Version {
+		return n.ruleset, true
+	}
+	rs, ok := n.rulesets[version]
+	return rs, ok
+}
+
+// loadedReferenceData lists the versions of every reference data set the
+// node has loaded, keyed by data set
+func (n *Node) loadedReferenceData() map[string][]string {
+	versions := map[string][]string{}
+
+	for system, loaded := range n.codeSets.Versions() {
+		versions["codeset/"+string(system)] = loaded
+	}
+	if n.feeSchedule.Loaded() {
+		versions["fee_schedule"] = n.feeSchedule.Versions()
+	}
+	if n.ncciEdits.Loaded() {
+		versions["ncci/ptp"], versions["ncci/mue"] = n.ncciEdits.Versions()
+	}
+	if n.coverage.Loaded() {
+		ids := n.coverage.IDs()
+		sort.Strings(ids)
+		versions["coverage"] = ids
+	}
+	if n.documents.Loaded() {
+		ids := n.documents.IDs()
+		sort.Strings(ids)
+		versions["attachments"] = ids
+	}
+	for _, p := range n.payers.Profiles() {
+		versions["payer/"+p.ID] = []string{p.Hash}
+	}
+	for _, p := range n.plugins.Rules() {
+		versions["plugin/"+p.Name] = []string{p.Hash}
+	}
+
+	return versions
+}
+
+// rulesetInfo identifies a ruleset, the rule definitions and the reference
+// data in a single content hash
+func (n *Node) rulesetInfo(rs *Ruleset, evaluatedAt time.Time) *domain.RulesetInfo {
+	type ruleDefinition struct {
+		Name     string `json:"name"`
+		Severity string `json:"severity"`
+	}
+
+	rules := make([]ruleDefinition, 0, len(n.rules))
+	for _, rule := range n.rules {
+		if !rs.Disabled(rule.Name) {
+			rules = append(rules, ruleDefinition{Name: rule.Name, Severity: rule.Severity})
+		}
+	}
+
+	// Map keys marshal in sorted order, so the encoding is stable
+	encoded, _ := json.Marshal(struct {
+		Ruleset       *Ruleset            `json:"ruleset"`
+		Rules         []ruleDefinition    `json:"rules"`
+		ReferenceData map[string][]string `json:"reference_data"`
+	}{rs, rules, n.referenceData})
+	sum := sha256.Sum256(encoded)
+
+	return &domain.RulesetInfo{
+		Version:       rs.Version,
+		Hash:          "0x" + hex.EncodeToString(sum[:]),
+		ReferenceData: n.referenceData,
+		EvaluatedAt:   evaluatedAt,
+	}
+}
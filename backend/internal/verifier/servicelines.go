@@ -0,0 +1,255 @@
+package verifier
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/saintparish4/apx/internal/domain"
+)
+
+const (
+	maxLineModifiers = 4
+	maxLinePointers  = 4
+)
+
+var modifierRegex = regexp.MustCompile(`^[0-9A-Z]{2}$`)
+
+// procedureLine is a billed procedure with its modifiers, units, charge and
+// the diagnoses it is billed for. Path locates the procedure code in the
+// claim for findings and UnitsPath its units.
+type procedureLine struct {
+	Path      string
+	UnitsPath string
+	Code      string
+	Modifiers []string
+	Units     int
+	Charge    domain.Money
+	Diagnoses []string // nil when the line does not point at specific diagnoses
+}
+
+// submittedProcedure is a procedure code as the claim carries it, before
+// parsing
+type submittedProcedure struct {
+	Path string
+	Code string
+}
+
+// submittedProcedures lists the claim's procedure codes from its service
+// lines, or from the flat procedure codes when it has none. Institutional
+// lines billed by revenue code alone carry no procedure.
+func submittedProcedures(data *domain.ClaimData) []submittedProcedure {
+	if len(data.ServiceLines) > 0 {
+		procedures := []submittedProcedure{}
+		for i, line := range data.ServiceLines {
+			if revenueOnly(line) {
+				continue
+			}
+			procedures = append(procedures, submittedProcedure{Path: serviceLinePath(i, "procedure_code"), Code: line.ProcedureCode})
+		}
+		return procedures
+	}
+
+	procedures := make([]submittedProcedure, len(data.ProcedureCodes))
+	for i, code := range data.ProcedureCodes {
+		procedures[i] = submittedProcedure{Path: fieldPath("procedure_codes", i), Code: code}
+	}
+	return procedures
+}
+
+// procedureLines returns one line per billed procedure: the claim's service
+// lines, or one line per flat procedure code. Flat claims only carry a total
+// billed amount, so it is apportioned evenly across their lines to the cent
+// (Charge is zero when the amount is missing or not positive). Lines with
+// malformed codes are left out, and ok is false if there were any.
+func procedureLines(data *domain.ClaimData) (lines []procedureLine, ok bool) {
+	if len(data.ServiceLines) > 0 {
+		return serviceLineProcedures(data)
+	}
+	if len(data.ProcedureCodes) == 0 {
+		return nil, false
+	}
+
+	charges := make([]domain.Money, len(data.ProcedureCodes))
+	if data.BilledAmount != nil && *data.BilledAmount > 0 {
+		charges = data.BilledAmount.Split(len(data.ProcedureCodes))
+	}
+
+	ok = true
+	for i, code := range data.ProcedureCodes {
+		base, modifiers, valid := splitProcedureCode(code)
+		if !valid {
+			ok = false
+			continue
+		}
+		lines = append(lines, procedureLine{
+			Path:      fieldPath("procedure_codes", i),
+			UnitsPath: fieldPath("procedure_codes", i),
+			Code:      base,
+			Modifiers: modifiers,
+			Units:     1,
+			Charge:    charges[i],
+		})
+	}
+
+	return lines, ok
+}
+
+func serviceLineProcedures(data *domain.ClaimData) (lines []procedureLine, ok bool) {
+	ok = true
+	for i, line := range data.ServiceLines {
+		if revenueOnly(line) {
+			continue
+		}
+
+		base, embedded, valid := splitProcedureCode(line.ProcedureCode)
+		if !valid || len(embedded) > 0 {
+			ok = false
+			continue
+		}
+
+		modifiers := make([]string, len(line.Modifiers))
+		for j, modifier := range line.Modifiers {
+			modifiers[j] = strings.ToUpper(strings.TrimSpace(modifier))
+		}
+
+		var diagnoses []string
+		for _, pointer := range line.DiagnosisPointers {
+			if pointer >= 1 && pointer <= len(data.DiagnosisCodes) {
+				diagnoses = append(diagnoses, data.DiagnosisCodes[pointer-1])
+			}
+		}
+
+		lines = append(lines, procedureLine{
+			Path:      serviceLinePath(i, "procedure_code"),
+			UnitsPath: serviceLinePath(i, "units"),
+			Code:      base,
+			Modifiers: modifiers,
+			Units:     line.Units,
+			Charge:    line.Charge,
+			Diagnoses: diagnoses,
+		})
+	}
+
+	return lines, ok
+}
+
+// revenueOnly reports whether a line is billed by revenue code without a
+// procedure code, as institutional room and board lines are
+func revenueOnly(line domain.ServiceLine) bool {
+	return line.ProcedureCode == "" && line.RevenueCode != ""
+}
+
+// serviceLinePath locates a field of a service line, e.g.
+// service_lines[2].units
+func serviceLinePath(index int, field string) string {
+	return fieldPath("service_lines", index) + "." + field
+}
+
+// checkServiceLines validates the structure of itemized service lines and
+// that they agree with the claim's totals and flat procedure codes. Procedure
+// codes themselves are checked by checkProcedureCodes.
+func (n *Node) checkServiceLines(ctx context.Context, data *Submission) []Violation {
+	if len(data.ServiceLines) == 0 {
+		return nil
+	}
+
+	violations := []Violation{}
+	var total domain.Money
+
+	for i, line := range data.ServiceLines {
+		if _, embedded, ok := splitProcedureCode(line.ProcedureCode); ok && len(embedded) > 0 {
+			violations = append(violations, violation(serviceLinePath(i, "procedure_code"), line.ProcedureCode,
+				"Modifiers on %s belong in %s", line.ProcedureCode, serviceLinePath(i, "modifiers"))...)
+		}
+
+		if len(line.Modifiers) > maxLineModifiers {
+			violations = append(violations, violation(serviceLinePath(i, "modifiers"), line.Modifiers,
+				"At most %d modifiers per service line, got %d", maxLineModifiers, len(line.Modifiers))...)
+		}
+		for j, modifier := range line.Modifiers {
+			if !modifierRegex.MatchString(strings.ToUpper(strings.TrimSpace(modifier))) {
+				violations = append(violations, violation(fieldPath(serviceLinePath(i, "modifiers"), j), modifier,
+					"Invalid modifier: %q", modifier)...)
+			}
+		}
+
+		if line.Units < 1 {
+			violations = append(violations, violation(serviceLinePath(i, "units"), line.Units,
+				"Units must be at least 1")...)
+		}
+
+		if line.Charge < 0 {
+			violations = append(violations, violation(serviceLinePath(i, "charge"), line.Charge,
+				"Line charge $%s must not be negative", line.Charge)...)
+		}
+		total += line.Charge
+
+		if len(line.DiagnosisPointers) > maxLinePointers {
+			violations = append(violations, violation(serviceLinePath(i, "diagnosis_pointers"), line.DiagnosisPointers,
+				"At most %d diagnosis pointers per service line, got %d", maxLinePointers, len(line.DiagnosisPointers))...)
+		}
+		for j, pointer := range line.DiagnosisPointers {
+			if pointer < 1 || pointer > len(data.DiagnosisCodes) {
+				violations = append(violations, violation(fieldPath(serviceLinePath(i, "diagnosis_pointers"), j), pointer,
+					"Diagnosis pointer %d does not refer to one of the %d diagnosis codes", pointer, len(data.DiagnosisCodes))...)
+			}
+		}
+	}
+
+	if data.BilledAmount != nil && total != *data.BilledAmount {
+		violations = append(violations, violation("service_lines", total,
+			"Service line charges total $%s but the billed amount is $%s", total, *data.BilledAmount)...)
+	}
+
+	// Flat codes sent alongside service lines for older readers must agree
+	if len(data.ProcedureCodes) > 0 && !sameCodes(flatCodes(data.ProcedureCodes), serviceLineCodes(data.ServiceLines)) {
+		violations = append(violations, violation("procedure_codes", data.ProcedureCodes,
+			"Procedure codes do not match the service lines")...)
+	}
+
+	return violations
+}
+
+// flatCodes returns the sorted base codes of procedure codes, keeping
+// malformed codes as submitted
+func flatCodes(codes []string) []string {
+	bases := []string{}
+	for _, code := range codes {
+		base, _, ok := splitProcedureCode(code)
+		if !ok {
+			base = code
+		}
+		bases = append(bases, base)
+	}
+	sort.Strings(bases)
+	return bases
+}
+
+// serviceLineCodes returns the sorted base codes of service lines that carry
+// a procedure
+func serviceLineCodes(lines []domain.ServiceLine) []string {
+	codes := []string{}
+	for _, line := range lines {
+		if !revenueOnly(line) {
+			codes = append(codes, line.ProcedureCode)
+		}
+	}
+	return flatCodes(codes)
+}
+
+// sortedServiceLines orders a copy of the lines canonically, so claims that
+// list the same services in a different order compare equal
+func sortedServiceLines(lines []domain.ServiceLine) []domain.ServiceLine {
+	sorted := append([]domain.ServiceLine(nil), lines...)
+	key := func(line domain.ServiceLine) string {
+		return fmt.Sprintf("%s|%s|%s|%d|%d|%v|%s|%s", line.ProcedureCode, line.RevenueCode, strings.Join(line.Modifiers, ","),
+			line.Units, line.Charge, line.DiagnosisPointers, line.Tooth, line.Surfaces)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return key(sorted[i]) < key(sorted[j])
+	})
+	return sorted
+}
@@ -0,0 +1,566 @@
+package x12
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/saintparish4/apx/internal/domain"
+)
+
+// Implementation guides by ST03 version, and the claim type each carries
+var implementations = map[string]string{
+	"005010X222A1": domain.ClaimTypeProfessional,
+	"005010X223A2": domain.ClaimTypeInstitutional,
+	"005010X223A3": domain.ClaimTypeInstitutional,
+}
+
+// Claim is one claim (loop 2300) of an 837 transaction. Data is only
+// complete when Errors is empty.
+type Claim struct {
+	Transaction          string            `json:"transaction"`            // ST02 control number
+	PatientControlNumber string            `json:"patient_control_number"` // CLM01
+	Data                 *domain.ClaimData `json:"claim_data"`
+	Errors               []*SegmentError   `json:"errors,omitempty"`
+}
+
+// Batch holds the claims of an interchange and the problems found outside
+// any claim
+type Batch struct {
+	Claims []*Claim        `json:"claims"`
+	Errors []*SegmentError `json:"errors,omitempty"`
+}
+
+// Parse837 maps every claim in an interchange of 837P and 837I transactions
+// to ClaimData. A malformed claim is reported with its segment errors and
+// does not stop the others; only an unreadable ISA header fails the parse.
+//
+// Member IDs and dates of birth are hashed with ids, never copied into
+// ClaimData. Attachment control numbers (PWK06) are taken to be IPFS CIDs.
+func Parse837(data string, ids *domain.IdentifierHasher) (*Batch, error) {
+	segments, delimiters, err := Split(data)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser837{delimiters: delimiters, ids: ids, batch: &Batch{Claims: []*Claim{}}}
+	for _, segment := range segments {
+		p.segment(segment)
+	}
+	p.finishClaim()
+	if p.transaction != nil {
+		p.batch.Errors = append(p.batch.Errors, errorAt(p.transaction, 0, "transaction %s has no SE trailer", p.transaction.Element(2)))
+	}
+
+	return p.batch, nil
+}
+
+// party is a subscriber or patient
+type party struct {
+	id  string
+	dob string // YYYY-MM-DD
+}
+
+type parser837 struct {
+	delimiters Delimiters
+	ids        *domain.IdentifierHasher
+	batch      *Batch
+
+	// Transaction (ST-SE)
+	transaction *Segment
+	claimType   string
+
+	// Hierarchical levels (HL) the next claim belongs to
+	billingNPI string
+	subscriber party
+	patient    *party
+	payerID    string
+	entity     string // NM101 of the last subscriber or patient name, for DMG
+
+	// Current claim (CLM) and service line (LX)
+	claim         *Claim
+	inLine        bool
+	lineDates     []string
+	statementFrom string
+	statementTo   string
+	patientStatus string
+	claimSegment  *Segment
+}
+
+func (p *parser837) fail(s *Segment, element int, format string, args ...interface{}) {
+	err := errorAt(s, element, format, args...)
+	if p.claim != nil {
+		p.claim.Errors = append(p.claim.Errors, err)
+		return
+	}
+	p.batch.Errors = append(p.batch.Errors, err)
+}
+
+func (p *parser837) segment(s *Segment) {
+	switch s.ID {
+	case "ST":
+		p.startTransaction(s)
+	case "SE":
+		p.endTransaction(s)
+	case "HL":
+		p.finishClaim()
+		p.hierarchy(s)
+	case "NM1":
+		p.name(s)
+	case "DMG":
+		p.demographics(s)
+	case "CLM":
+		p.finishClaim()
+		p.startClaim(s)
+	case "LX":
+		if p.claim != nil {
+			p.inLine = true
+		}
+	case "DTP":
+		p.date(s)
+	case "CL1":
+		if p.claim != nil {
+			p.patientStatus = s.Element(3)
+		}
+	case "REF":
+		if p.claim != nil && !p.inLine && s.Element(1) == "F8" {
+			p.claim.Data.OriginalClaimID = s.Element(2)
+		}
+	case "HI":
+		p.healthCare(s)
+	case "PWK":
+		p.attachment(s)
+	case "SV1":
+		p.professionalService(s)
+	case "SV2":
+		p.institutionalService(s)
+	}
+}
+
+func (p *parser837) startTransaction(s *Segment) {
+	p.finishClaim()
+	if p.transaction != nil {
+		p.fail(p.transaction, 0, "transaction %s has no SE trailer", p.transaction.Element(2))
+	}
+
+	p.transaction = s
+	p.claimType = ""
+	p.billingNPI, p.subscriber, p.patient, p.payerID = "", party{}, nil, ""
+
+	if s.Element(1) != "837" {
+		p.fail(s, 1, "transaction set %s is not an 837", s.Element(1))
+		return
+	}
+	claimType, ok := implementations[s.Element(3)]
+	if !ok {
+		p.fail(s, 3, "unsupported implementation guide %q; expected 837P (005010X222A1) or 837I (005010X223A2)", s.Element(3))
+		return
+	}
+	p.claimType = claimType
+}
+
+func (p *parser837) endTransaction(s *Segment) {
+	p.finishClaim()
+	if p.transaction == nil {
+		p.fail(s, 0, "SE without ST")
+		return
+	}
+
+	count := s.Position - p.transaction.Position + 1
+	if s.Element(1) != strconv.Itoa(count) {
+		p.fail(s, 1, "segment count %s does not match the %d segments in the transaction", s.Element(1), count)
+	}
+	if s.Element(2) != p.transaction.Element(2) {
+		p.fail(s, 2, "control number %s does not match ST02 %s", s.Element(2), p.transaction.Element(2))
+	}
+	p.transaction = nil
+}
+
+func (p *parser837) hierarchy(s *Segment) {
+	switch s.Element(3) {
+	case "20": // billing provider
+		p.billingNPI = ""
+		p.subscriber, p.patient, p.payerID = party{}, nil, ""
+	case "22": // subscriber
+		p.subscriber, p.patient, p.payerID = party{}, nil, ""
+	case "23": // patient, when not the subscriber
+		p.patient = &party{}
+	default:
+		p.fail(s, 3, "unknown hierarchical level code %q", s.Element(3))
+	}
+}
+
+func (p *parser837) name(s *Segment) {
+	id := s.Element(9)
+
+	switch s.Element(1) {
+	case "85": // billing provider
+		if s.Element(8) != "XX" {
+			p.fail(s, 8, "billing provider must be identified by NPI (XX), got %q", s.Element(8))
+			return
+		}
+		p.billingNPI = id
+	case "IL": // subscriber
+		p.subscriber.id = id
+		p.entity = "IL"
+	case "QC": // patient; 5010 identifies dependents through the subscriber
+		if p.patient == nil {
+			p.patient = &party{}
+		}
+		p.patient.id = domain.DependentIdentifier(p.subscriber.id, s.Element(3), s.Element(4))
+		p.entity = "QC"
+	case "PR": // payer
+		p.payerID = id
+	case "77": // service facility
+		if p.claim != nil && s.Element(8) == "XX" {
+			p.claim.Data.FacilityID = id
+		}
+	}
+}
+
+func (p *parser837) demographics(s *Segment) {
+	if s.Element(1) != "D8" {
+		p.fail(s, 1, "date of birth format must be D8, got %q", s.Element(1))
+		return
+	}
+	dob, err := parseDate(s.Element(2))
+	if err != nil {
+		p.fail(s, 2, "invalid date of birth: %v", err)
+		return
+	}
+
+	switch {
+	case p.entity == "QC" && p.patient != nil:
+		p.patient.dob = dob
+	case p.entity == "IL":
+		p.subscriber.dob = dob
+	}
+}
+
+func (p *parser837) startClaim(s *Segment) {
+	p.claim = &Claim{
+		PatientControlNumber: s.Element(1),
+		Data: &domain.ClaimData{
+			ClaimType:            p.claimType,
+			ProviderNPI:          p.billingNPI,
+			PayerID:              p.payerID,
+			PatientControlNumber: s.Element(1),
+		},
+	}
+	if p.transaction != nil {
+		p.claim.Transaction = p.transaction.Element(2)
+	}
+	p.claimSegment = s
+	p.inLine = false
+	p.lineDates = nil
+	p.statementFrom, p.statementTo, p.patientStatus = "", "", ""
+
+	if p.transaction == nil {
+		p.fail(s, 0, "claim outside a transaction")
+	} else if p.claimType == "" {
+		p.fail(s, 0, "claim in an unsupported transaction")
+	}
+	if s.Element(1) == "" {
+		p.fail(s, 1, "missing patient control number")
+	}
+
+	if amount, err := parseAmount(s.Element(2)); err != nil {
+		p.fail(s, 2, "invalid claim charge amount: %v", err)
+	} else {
+		p.claim.Data.BilledAmount = &amount
+	}
+
+	// CLM05: facility code : facility code qualifier : frequency code
+	facility := p.delimiters.components(s.Element(5))
+	if len(facility) < 3 || facility[0] == "" || facility[2] == "" {
+		p.fail(s, 5, "facility code and claim frequency are required, got %q", s.Element(5))
+		return
+	}
+	switch p.claimType {
+	case domain.ClaimTypeProfessional:
+		p.claim.Data.PlaceOfService = facility[0]
+	case domain.ClaimTypeInstitutional:
+		p.claim.Data.TypeOfBill = "0" + facility[0] + facility[2]
+	}
+}
+
+func (p *parser837) date(s *Segment) {
+	if p.claim == nil {
+		return
+	}
+
+	switch s.Element(1) {
+	case "472": // service date
+		from, _, err := parseDatePeriod(s.Element(2), s.Element(3))
+		if err != nil {
+			p.fail(s, 3, "invalid service date: %v", err)
+			return
+		}
+		p.lineDates = append(p.lineDates, from)
+	case "434": // statement period
+		from, through, err := parseDatePeriod(s.Element(2), s.Element(3))
+		if err != nil {
+			p.fail(s, 3, "invalid statement dates: %v", err)
+			return
+		}
+		p.statementFrom, p.statementTo = from, through
+	case "435": // admission
+		admission, _, err := parseDatePeriod(s.Element(2), s.Element(3))
+		if err != nil {
+			p.fail(s, 3, "invalid admission date: %v", err)
+			return
+		}
+		p.claim.Data.AdmissionDate = admission
+	}
+}
+
+func (p *parser837) healthCare(s *Segment) {
+	if p.claim == nil {
+		return
+	}
+
+	for i := 1; i < len(s.Elements); i++ {
+		if s.Elements[i] == "" {
+			continue
+		}
+		parts := p.delimiters.components(s.Elements[i])
+		qualifier, code := parts[0], ""
+		if len(parts) > 1 {
+			code = parts[1]
+		}
+
+		switch qualifier {
+		case "ABK", "ABF": // principal and other ICD-10-CM diagnoses
+			if code == "" {
+				p.fail(s, i, "missing diagnosis code")
+				continue
+			}
+			p.claim.Data.DiagnosisCodes = append(p.claim.Data.DiagnosisCodes, icd10(code))
+		case "BK", "BF":
+			p.fail(s, i, "ICD-9-CM diagnoses (%s) are not supported", qualifier)
+		case "DR":
+			p.claim.Data.DRGCode = code
+		}
+	}
+}
+
+func (p *parser837) attachment(s *Segment) {
+	if p.claim == nil || p.inLine {
+		return
+	}
+	if s.Element(6) == "" {
+		p.fail(s, 6, "attachment control number (IPFS CID) is required")
+		return
+	}
+	p.claim.Data.Attachments = append(p.claim.Data.Attachments, domain.Attachment{
+		CID:        s.Element(6),
+		ReportType: s.Element(1),
+	})
+}
+
+func (p *parser837) professionalService(s *Segment) {
+	if p.claim == nil {
+		return
+	}
+	if p.claimType != domain.ClaimTypeProfessional {
+		p.fail(s, 0, "SV1 is only used in 837P")
+		return
+	}
+
+	line := domain.ServiceLine{}
+
+	procedure := p.delimiters.components(s.Element(1))
+	if procedure[0] != "HC" || len(procedure) < 2 || procedure[1] == "" {
+		p.fail(s, 1, "procedure must be an HCPCS/CPT code (HC), got %q", s.Element(1))
+		return
+	}
+	line.ProcedureCode = procedure[1]
+	for _, modifier := range procedure[2:] {
+		if modifier != "" {
+			line.Modifiers = append(line.Modifiers, modifier)
+		}
+	}
+
+	if !p.lineCharge(s, 2, &line) || !p.lineUnits(s, 4, &line) {
+		return
+	}
+
+	if pointers := s.Element(7); pointers != "" {
+		for _, pointer := range p.delimiters.components(pointers) {
+			index, err := strconv.Atoi(pointer)
+			if err != nil {
+				p.fail(s, 7, "invalid diagnosis pointer %q", pointer)
+				return
+			}
+			line.DiagnosisPointers = append(line.DiagnosisPointers, index)
+		}
+	}
+
+	p.claim.Data.ServiceLines = append(p.claim.Data.ServiceLines, line)
+}
+
+func (p *parser837) institutionalService(s *Segment) {
+	if p.claim == nil {
+		return
+	}
+	if p.claimType != domain.ClaimTypeInstitutional {
+		p.fail(s, 0, "SV2 is only used in 837I")
+		return
+	}
+
+	line := domain.ServiceLine{RevenueCode: s.Element(1)}
+	if line.RevenueCode == "" {
+		p.fail(s, 1, "missing revenue code")
+		return
+	}
+
+	if s.Element(2) != "" {
+		procedure := p.delimiters.components(s.Element(2))
+		if procedure[0] != "HC" || len(procedure) < 2 || procedure[1] == "" {
+			p.fail(s, 2, "procedure must be an HCPCS/CPT code (HC), got %q", s.Element(2))
+			return
+		}
+		line.ProcedureCode = procedure[1]
+		for _, modifier := range procedure[2:] {
+			if modifier != "" {
+				line.Modifiers = append(line.Modifiers, modifier)
+			}
+		}
+	}
+
+	if !p.lineCharge(s, 3, &line) || !p.lineUnits(s, 5, &line) {
+		return
+	}
+
+	p.claim.Data.ServiceLines = append(p.claim.Data.ServiceLines, line)
+}
+
+func (p *parser837) lineCharge(s *Segment, element int, line *domain.ServiceLine) bool {
+	charge, err := parseAmount(s.Element(element))
+	if err != nil {
+		p.fail(s, element, "invalid line charge: %v", err)
+		return false
+	}
+	line.Charge = charge
+	return true
+}
+
+func (p *parser837) lineUnits(s *Segment, element int, line *domain.ServiceLine) bool {
+	value := s.Element(element)
+	units, err := strconv.ParseFloat(value, 64)
+	if err != nil || units != math.Trunc(units) || units > math.MaxInt32 {
+		p.fail(s, element, "units must be a whole number, got %q", value)
+		return false
+	}
+	line.Units = int(units)
+	return true
+}
+
+// finishClaim fills in what is only known once the whole claim has been read
+func (p *parser837) finishClaim() {
+	claim := p.claim
+	if claim == nil {
+		return
+	}
+	p.claim = nil
+	data := claim.Data
+
+	patient := p.subscriber
+	if p.patient != nil && p.patient.id != "" {
+		patient = *p.patient
+	}
+	if patient.id != "" {
+		data.PatientID = p.ids.Hash(patient.id)
+	}
+	if patient.dob != "" {
+		data.PatientDOBHash = p.ids.Hash(patient.dob)
+	}
+
+	switch data.ClaimType {
+	case domain.ClaimTypeInstitutional:
+		data.ServiceDate = p.statementFrom
+		// Patient status 30 means still a patient, so the stay has not ended
+		if data.AdmissionDate != "" && p.patientStatus != "30" {
+			data.DischargeDate = p.statementTo
+		}
+		if data.ServiceDate == "" {
+			claim.Errors = append(claim.Errors, errorAt(p.claimSegment, 0, "claim has no statement dates (DTP*434)"))
+		}
+	case domain.ClaimTypeProfessional:
+		// ClaimData carries one date of service: the earliest line's
+		if len(p.lineDates) > 0 {
+			sort.Strings(p.lineDates)
+			data.ServiceDate = p.lineDates[0]
+		} else {
+			claim.Errors = append(claim.Errors, errorAt(p.claimSegment, 0, "claim has no service dates (DTP*472)"))
+		}
+	}
+
+	if len(data.ServiceLines) == 0 && len(claim.Errors) == 0 {
+		claim.Errors = append(claim.Errors, errorAt(p.claimSegment, 0, "claim has no service lines"))
+	}
+
+	p.batch.Claims = append(p.batch.Claims, claim)
+}
+
+// icd10 restores the decimal point X12 omits, e.g. S72001A to S72.001A
+func icd10(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) > 3 && !strings.Contains(code, ".") {
+		return code[:3] + "." + code[3:]
+	}
+	return code
+}
+
+// parseAmount parses an X12 decimal amount, which may omit the leading zero
+func parseAmount(value string) (domain.Money, error) {
+	switch {
+	case strings.HasPrefix(value, "."):
+		value = "0" + value
+	case strings.HasPrefix(value, "-."):
+		value = "-0" + value[1:]
+	}
+	return domain.ParseMoney(value)
+}
+
+// parseDate converts a CCYYMMDD date to YYYY-MM-DD
+func parseDate(value string) (string, error) {
+	date, err := time.Parse("20060102", value)
+	if err != nil {
+		return "", fmt.Errorf("%q is not CCYYMMDD", value)
+	}
+	return date.Format("2006-01-02"), nil
+}
+
+// parseDatePeriod reads a D8 date, DT date-time or RD8 range and returns its
+// first and last dates as YYYY-MM-DD
+func parseDatePeriod(format, value string) (from, through string, err error) {
+	switch format {
+	case "D8":
+		from, err = parseDate(value)
+		return from, from, err
+	case "DT":
+		if len(value) < 8 {
+			return "", "", fmt.Errorf("%q is not CCYYMMDDHHMM", value)
+		}
+		from, err = parseDate(value[:8])
+		return from, from, err
+	case "RD8":
+		start, end, ok := strings.Cut(value, "-")
+		if !ok {
+			return "", "", fmt.Errorf("%q is not CCYYMMDD-CCYYMMDD", value)
+		}
+		if from, err = parseDate(start); err != nil {
+			return "", "", err
+		}
+		if through, err = parseDate(end); err != nil {
+			return "", "", err
+		}
+		return from, through, nil
+	default:
+		return "", "", fmt.Errorf("unsupported date format %q", format)
+	}
+}
@@ -0,0 +1,217 @@
+package x12
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/saintparish4/apx/internal/domain"
+)
+
+// Implementation guide of the 835 remittances written by Write835
+const implementation835 = "005010X221A1"
+
+// originatorID identifies the network as the originator of 835 trace
+// numbers (TRN03)
+const originatorID = "1APXNETWRK"
+
+// CLP02 claim status codes
+const (
+	ClaimProcessed = "1" // processed as primary
+	ClaimDenied    = "4"
+)
+
+// CAS01 claim adjustment group codes
+const (
+	GroupContractual           = "CO"
+	GroupPatientResponsibility = "PR"
+	GroupOther                 = "OA"
+)
+
+// Remittance is an 835 interchange to a single payee, holding one
+// transaction per payer
+type Remittance struct {
+	SenderID      string // ISA06, up to 15 characters
+	ReceiverID    string // ISA08, up to 15 characters
+	ControlNumber int    // ISA13 and GS06, up to 9 digits
+	Created       time.Time
+
+	PayeeName string
+	PayeeNPI  string
+
+	Payments []*Payment
+}
+
+// Payment is the remittance of one payer: an 835 transaction
+type Payment struct {
+	PayerID   string
+	PayerName string
+	Claims    []*ClaimPayment
+}
+
+// ClaimPayment is one adjudicated claim (loop 2100). Charge minus Paid must
+// equal the sum of the adjustments.
+type ClaimPayment struct {
+	PatientControlNumber  string // CLP01, up to 38 characters
+	PayerClaimID          string // CLP07, up to 50 characters
+	Status                string // ClaimProcessed or ClaimDenied
+	Charge                domain.Money
+	Paid                  domain.Money
+	PatientResponsibility domain.Money
+	FacilityCode          string // CLP08: place of service or the first two digits of the type of bill
+	Frequency             string // CLP09
+	Inpatient             bool   // remarks go in MIA rather than MOA
+
+	PatientID   string // NM109, hashed member ID
+	ServiceDate string // YYYY-MM-DD
+	Received    time.Time
+
+	Adjustments []Adjustment
+	Remarks     []string // RARC remark codes, at most five
+}
+
+// Adjustment is a CARC adjustment of a claim (CAS)
+type Adjustment struct {
+	Group  string // GroupContractual, GroupPatientResponsibility or GroupOther
+	Reason string // CARC, e.g. 16
+	Amount domain.Money
+}
+
+// Write835 writes a remittance as an X12 5010 835 interchange. Segments are
+// terminated with ~ and a line break, elements separated by *.
+func Write835(r *Remittance) string {
+	w := &writer{}
+	created := r.Created.UTC()
+	control := fmt.Sprintf("%09d", r.ControlNumber%1000000000)
+
+	w.segment("ISA", "00", pad("", 10), "00", pad("", 10),
+		"ZZ", pad(r.SenderID, 15), "ZZ", pad(r.ReceiverID, 15),
+		created.Format("060102"), created.Format("1504"), "^", "00501", control, "0", "P", ":")
+	w.segment("GS", "HP", r.SenderID, r.ReceiverID, created.Format("20060102"), created.Format("1504"),
+		fmt.Sprint(r.ControlNumber%1000000000), "X", implementation835)
+
+	for i, payment := range r.Payments {
+		w.transaction(r, payment, fmt.Sprintf("%04d", i+1), control)
+	}
+
+	w.segment("GE", fmt.Sprint(len(r.Payments)), fmt.Sprint(r.ControlNumber%1000000000))
+	w.segment("IEA", "1", control)
+	return w.String()
+}
+
+type writer struct {
+	strings.Builder
+	count int // segments written since the last ST
+}
+
+func (w *writer) segment(id string, elements ...string) {
+	// Trailing empty elements are omitted
+	for len(elements) > 0 && elements[len(elements)-1] == "" {
+		elements = elements[:len(elements)-1]
+	}
+	w.WriteString(id)
+	for _, element := range elements {
+		w.WriteByte('*')
+		w.WriteString(element)
+	}
+	w.WriteString("~\n")
+	w.count++
+}
+
+func (w *writer) transaction(r *Remittance, payment *Payment, number, control string) {
+	total := domain.Money(0)
+	for _, claim := range payment.Claims {
+		total += claim.Paid
+	}
+	created := r.Created.UTC().Format("20060102")
+
+	w.count = 0
+	w.segment("ST", "835", number, implementation835)
+	// No funds move through the network, so the 835 is a notification only
+	w.segment("BPR", "H", total.String(), "C", "NON", "", "", "", "", "", "", "", "", "", "", "", created)
+	w.segment("TRN", "1", control+number, originatorID)
+	w.segment("DTM", "405", created)
+
+	w.segment("N1", "PR", payment.PayerName)
+	if payment.PayerID != "" {
+		w.segment("REF", "2U", payment.PayerID)
+	}
+	w.segment("N1", "PE", r.PayeeName, "XX", r.PayeeNPI)
+
+	if len(payment.Claims) > 0 {
+		w.segment("LX", "1")
+	}
+	for _, claim := range payment.Claims {
+		w.claim(claim)
+	}
+
+	w.segment("SE", fmt.Sprint(w.count+1), number)
+}
+
+func (w *writer) claim(c *ClaimPayment) {
+	w.segment("CLP", c.PatientControlNumber, c.Status, c.Charge.String(), c.Paid.String(),
+		moneyOrEmpty(c.PatientResponsibility), "ZZ", c.PayerClaimID, c.FacilityCode, c.Frequency)
+
+	// CAS holds up to six adjustments of a group
+	var groups []string
+	byGroup := map[string][]string{}
+	for _, adjustment := range c.Adjustments {
+		if _, seen := byGroup[adjustment.Group]; !seen {
+			groups = append(groups, adjustment.Group)
+		}
+		byGroup[adjustment.Group] = append(byGroup[adjustment.Group], adjustment.Reason, adjustment.Amount.String(), "")
+	}
+	for _, group := range groups {
+		elements := byGroup[group]
+		for len(elements) > 0 {
+			n := len(elements)
+			if n > 18 {
+				n = 18
+			}
+			w.segment("CAS", append([]string{group}, elements[:n]...)...)
+			elements = elements[n:]
+		}
+	}
+
+	if c.PatientID != "" {
+		w.segment("NM1", "QC", "1", "", "", "", "", "", "MI", c.PatientID)
+	}
+
+	remarks := c.Remarks
+	if len(remarks) > 5 {
+		remarks = remarks[:5]
+	}
+	if len(remarks) > 0 {
+		if c.Inpatient {
+			// MIA05 holds the first remark, MIA20-MIA23 the rest
+			elements := make([]string, 23)
+			elements[0], elements[4] = "0", remarks[0]
+			copy(elements[19:], remarks[1:])
+			w.segment("MIA", elements...)
+		} else {
+			w.segment("MOA", append([]string{"", ""}, remarks...)...)
+		}
+	}
+
+	if c.ServiceDate != "" {
+		w.segment("DTM", "232", strings.ReplaceAll(c.ServiceDate, "-", ""))
+	}
+	if !c.Received.IsZero() {
+		w.segment("DTM", "050", c.Received.UTC().Format("20060102"))
+	}
+}
+
+func moneyOrEmpty(m domain.Money) string {
+	if m == 0 {
+		return ""
+	}
+	return m.String()
+}
+
+// pad left-justifies a fixed-width ISA element
+func pad(value string, width int) string {
+	if len(value) > width {
+		return value[:width]
+	}
+	return value + strings.Repeat(" ", width-len(value))
+}
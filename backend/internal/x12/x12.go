@@ -0,0 +1,96 @@
+// Package x12 reads and writes ANSI X12 5010 interchanges. It splits an
+// interchange into segments using the delimiters declared in its ISA header,
+// maps 837 Professional and Institutional claims into domain.ClaimData and
+// writes 835 remittance advice for adjudicated claims.
+package x12
+
+import (
+	"fmt"
+	"strings"
+)
+
+// isaLength is the fixed length of an ISA segment including its terminator
+const isaLength = 106
+
+// Segment is one segment of an interchange
+type Segment struct {
+	Position int      // 1-based position in the interchange
+	ID       string   // e.g. CLM
+	Elements []string // Elements[0] is the segment ID, so Elements[1] is CLM01
+}
+
+// Element returns the element at a 1-based position, or "" if absent
+func (s *Segment) Element(position int) string {
+	if position < len(s.Elements) {
+		return s.Elements[position]
+	}
+	return ""
+}
+
+// SegmentError is a problem with a single segment
+type SegmentError struct {
+	Position int    `json:"segment"`           // 1-based position in the interchange
+	ID       string `json:"segment_id"`        // e.g. SV1
+	Element  int    `json:"element,omitempty"` // 1-based element position, 0 for the whole segment
+	Message  string `json:"message"`
+}
+
+func (e *SegmentError) Error() string {
+	if e.Element > 0 {
+		return fmt.Sprintf("segment %d (%s%02d): %s", e.Position, e.ID, e.Element, e.Message)
+	}
+	return fmt.Sprintf("segment %d (%s): %s", e.Position, e.ID, e.Message)
+}
+
+// errorAt builds a SegmentError for an element of a segment
+func errorAt(s *Segment, element int, format string, args ...interface{}) *SegmentError {
+	return &SegmentError{Position: s.Position, ID: s.ID, Element: element, Message: fmt.Sprintf(format, args...)}
+}
+
+// Delimiters are the separators an interchange declares in its ISA header
+type Delimiters struct {
+	Element    byte
+	Component  byte
+	Repetition byte
+	Segment    byte
+}
+
+// Split reads the delimiters from the ISA header and splits an interchange
+// into segments. Line breaks after segment terminators are ignored.
+func Split(data string) ([]*Segment, Delimiters, error) {
+	data = strings.TrimLeft(data, " \t\r\n\ufeff")
+	if len(data) < isaLength || !strings.HasPrefix(data, "ISA") {
+		return nil, Delimiters{}, &SegmentError{Position: 1, ID: "ISA", Message: "interchange must start with a 106-character ISA segment"}
+	}
+
+	delimiters := Delimiters{
+		Element:    data[3],
+		Repetition: data[82],
+		Component:  data[104],
+		Segment:    data[105],
+	}
+	if delimiters.Element == delimiters.Segment || delimiters.Element == delimiters.Component {
+		return nil, Delimiters{}, &SegmentError{Position: 1, ID: "ISA", Message: "element, component and segment delimiters must differ"}
+	}
+
+	var segments []*Segment
+	for _, raw := range strings.Split(data, string(delimiters.Segment)) {
+		raw = strings.Trim(raw, "\r\n")
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		elements := strings.Split(raw, string(delimiters.Element))
+		segments = append(segments, &Segment{
+			Position: len(segments) + 1,
+			ID:       strings.TrimSpace(elements[0]),
+			Elements: elements,
+		})
+	}
+
+	return segments, delimiters, nil
+}
+
+// components splits a composite element
+func (d Delimiters) components(element string) []string {
+	return strings.Split(element, string(d.Component))
+}
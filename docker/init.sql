@@ -42,6 +42,21 @@ CREATE INDEX idx_providers_wallet ON providers (wallet_address);
 CREATE INDEX idx_providers_status ON providers (status);
 CREATE INDEX idx_providers_npi ON providers (npi);
 
+-- NPPES registry subset (loaded by cmd/nppes-import)
+CREATE TABLE nppes_providers (
+    npi VARCHAR(10) PRIMARY KEY,
+    entity_type SMALLINT NOT NULL, -- 1 individual, 2 organization
+    name VARCHAR(255),
+    state VARCHAR(2),
+    taxonomy_code VARCHAR(10),
+    deactivation_date DATE,
+    reactivation_date DATE,
+    imported_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_nppes_deactivated ON nppes_providers (deactivation_date)
+    WHERE deactivation_date IS NOT NULL;
+
 -- Claims table (off-chain index with references to on-chain data)
 CREATE TABLE claims (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
@@ -91,6 +106,72 @@ CREATE INDEX idx_claims_submitted_at ON claims(submitted_at);
 CREATE INDEX idx_claims_procedure_codes ON claims USING GIN(procedure_codes);
 CREATE INDEX idx_claims_diagnosis_codes ON claims USING GIN(diagnosis_codes);
 
+-- Duplicate detection index (one row per claim processed by the verifier)
+CREATE TABLE claim_fingerprints (
+    claim_id VARCHAR(66) PRIMARY KEY,
+    content_hash VARCHAR(66) NOT NULL,
+    patient_id VARCHAR(128) NOT NULL,
+    provider_npi VARCHAR(10) NOT NULL,
+    service_date DATE NOT NULL,
+    procedure_codes TEXT[] NOT NULL,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_fingerprints_patient_date ON claim_fingerprints(patient_id, service_date);
+CREATE INDEX idx_fingerprints_content_hash ON claim_fingerprints(content_hash);
+
+-- Rolling per-provider billing baselines for anomaly scoring
+CREATE TABLE provider_baselines (
+    provider_npi VARCHAR(10) PRIMARY KEY,
+    baseline JSONB NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- This node's validation verdicts, kept with the ruleset that produced them
+-- so disputes can be replayed
+CREATE TABLE claim_verdicts (
+    claim_id VARCHAR(66) PRIMARY KEY,
+    ipfs_cid VARCHAR(100) NOT NULL,
+    provider_address VARCHAR(42) NOT NULL,
+    amount NUMERIC(78, 0), -- on-chain amount, 18 decimals; NULL before it was recorded
+    data_hash VARCHAR(66), -- on-chain data hash; NULL before it was recorded
+    ruleset_version VARCHAR(50) NOT NULL,
+    ruleset_hash VARCHAR(66) NOT NULL,
+    approved BOOLEAN NOT NULL,
+    score NUMERIC(5, 2) NOT NULL,
+    result JSONB NOT NULL,
+    validated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_verdicts_ruleset ON claim_verdicts(ruleset_hash);
+
+-- Claims parked for a human reviewer instead of an automatic vote
+CREATE TABLE review_queue (
+    claim_id VARCHAR(66) PRIMARY KEY,
+    ipfs_cid VARCHAR(100) NOT NULL,
+    provider_address VARCHAR(42) NOT NULL,
+    result JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    reviewer VARCHAR(100),
+    notes JSONB NOT NULL DEFAULT '[]',
+
+    -- Decision; the reason is submitted as the on-chain vote reason
+    approved BOOLEAN,
+    reason TEXT,
+
+    deadline TIMESTAMPTZ NOT NULL,
+    window_closes_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    decided_at TIMESTAMPTZ,
+    vote_tx_hash VARCHAR(66),
+    submitted_at TIMESTAMPTZ,
+
+    CONSTRAINT valid_review_status CHECK (status IN ('pending', 'claimed', 'decided', 'submitting', 'submitted', 'expired'))
+);
+
+CREATE INDEX idx_review_queue_status ON review_queue(status, deadline);
+
 -- Verifications table
 CREATE TABLE verifications (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
@@ -262,7 +343,7 @@ INSERT INTO providers (
     '0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266', -- Anvil default account
     '0x0000000000000000000000000000000000000000000000000000000000000000',
     'Development Provider',
-    '1234567890',
+    '1234567893', -- CMS example NPI (valid check digit)
     'active'
 ) ON CONFLICT (wallet_address) DO NOTHING;
 
@@ -306,7 +387,12 @@ ORDER BY c.submitted_at DESC
 LIMIT 100;
 
 COMMENT ON TABLE providers IS 'Healthcare providers registered in the network';
+COMMENT ON TABLE nppes_providers IS 'NPPES NPI registry records imported for verification';
 COMMENT ON TABLE claims IS 'Healthcare claims submitted for verification';
+COMMENT ON TABLE claim_fingerprints IS 'Claim fingerprints for cross-claim duplicate detection';
+COMMENT ON TABLE provider_baselines IS 'Rolling per-provider billing baselines for anomaly scoring';
+COMMENT ON TABLE claim_verdicts IS 'Validation verdicts with the ruleset version and hash that produced them';
+COMMENT ON TABLE review_queue IS 'Claims awaiting a human review decision before the verification window closes';
 COMMENT ON TABLE verifications IS 'Verification decisions from verifier nodes';
 COMMENT ON TABLE blockchain_events IS 'Raw blockchain events for processing';
 COMMENT ON TABLE daily_stats IS 'Aggregated daily statistics for analytics';
\ No newline at end of file